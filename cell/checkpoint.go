@@ -0,0 +1,86 @@
+package cell
+
+// The checkpoint states capture everything that changes while a
+// simulation runs. Structure (what is connected to what) isn't
+// captured, it is rebuilt from the simulation's definition.
+
+// NeuronState is a ProtoNeuron's checkpoint.
+type NeuronState struct {
+	Output    byte    `json:"output"`
+	Threshold float64 `json:"threshold"`
+	APDecay   float64 `json:"apDecay"`
+	APt       int     `json:"apT"`
+	PreAPt    int     `json:"preApT"`
+	MaxAP     float64 `json:"maxAP"`
+	TaoP      float64 `json:"taoP"`
+	TaoN      float64 `json:"taoN"`
+	TaoY      float64 `json:"taoY"`
+}
+
+func (n *ProtoNeuron) Snapshot() NeuronState {
+	return NeuronState{
+		Output:    n.output,
+		Threshold: n.threshold,
+		APDecay:   n.apDecay,
+		APt:       n.APt,
+		PreAPt:    n.preAPt,
+		MaxAP:     n.maxAP,
+		TaoP:      n.taoP,
+		TaoN:      n.taoN,
+		TaoY:      n.taoY,
+	}
+}
+
+func (n *ProtoNeuron) Restore(st NeuronState) {
+	n.output = st.Output
+	n.threshold = st.Threshold
+	n.apDecay = st.APDecay
+	n.APt = st.APt
+	n.preAPt = st.PreAPt
+	n.maxAP = st.MaxAP
+	n.taoP = st.TaoP
+	n.taoN = st.TaoN
+	n.taoY = st.TaoY
+}
+
+// SynapseState is a ProtoSynapse's weights and traces.
+type SynapseState struct {
+	ID   int     `json:"id"`
+	WI   float64 `json:"wI"`
+	WP   float64 `json:"wP"`
+	PreT int     `json:"preT"`
+	TaoP float64 `json:"taoP"`
+	TaoN float64 `json:"taoN"`
+}
+
+func (n *ProtoSynapse) Snapshot() SynapseState {
+	return SynapseState{
+		ID:   n.id,
+		WI:   n.wI,
+		WP:   n.wP,
+		PreT: n.preT,
+		TaoP: n.taoP,
+		TaoN: n.taoN,
+	}
+}
+
+func (n *ProtoSynapse) Restore(st SynapseState) {
+	n.wI = st.WI
+	n.wP = st.WP
+	n.preT = st.PreT
+	n.taoP = st.TaoP
+	n.taoN = st.TaoN
+}
+
+// ConnectionState is the data in flight on a StraightConnection.
+type ConnectionState struct {
+	Value byte `json:"value"`
+}
+
+func (sc *StraightConnection) Snapshot() ConnectionState {
+	return ConnectionState{Value: sc.value}
+}
+
+func (sc *StraightConnection) Restore(st ConnectionState) {
+	sc.value = st.Value
+}
@@ -48,6 +48,16 @@ func NewProtoNeuron() ICell {
 	return n
 }
 
+// Configure sets the soma and STDP properties.
+func (n *ProtoNeuron) Configure(threshold, apDecay, maxAP, taoP, taoN, taoY float64) {
+	n.threshold = threshold
+	n.apDecay = apDecay
+	n.maxAP = maxAP
+	n.taoP = taoP
+	n.taoN = taoN
+	n.taoY = taoY
+}
+
 func (n *ProtoNeuron) Output() byte {
 	return n.output
 }
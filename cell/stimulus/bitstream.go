@@ -1,6 +1,9 @@
 package stimulus
 
-import "github.com/wdevore/Deuron4/cell"
+import (
+	"github.com/wdevore/Deuron4/cell"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
 
 // IBitStream can either be input stimulus or neuron output spikes
 // This is a stream of spikes
@@ -40,3 +43,32 @@ type IPatternStream interface {
 	// returns true if patten complete during this step.
 	Step() bool
 }
+
+// IPatternContainer presents a pattern, one IPatternStream per
+// synapse, on a schedule.
+type IPatternContainer interface {
+	Add(IPatternStream)
+
+	// Iterate the streams: Begin, Stream, Next...
+	Begin() bool
+	Next() bool
+	Stream() IPatternStream
+
+	// Reset restarts the schedule and the pattern.
+	Reset()
+	// Step moves every stream on by 1ms.
+	Step()
+
+	SetSchedule(ISchedule)
+	Schedule() ISchedule
+
+	SetPerturbation(p Perturbation, src *rng.Source)
+	Perturbation() *Perturbation
+
+	// SetPatternSet presents labeled patterns in turn.
+	SetPatternSet(*PatternSet)
+	// Label of the current presentation's pattern.
+	Label() string
+	// Onset reports if a presentation started during the last step.
+	Onset() bool
+}
@@ -0,0 +1,115 @@
+package stimulus
+
+import (
+	"github.com/wdevore/Deuron4/cell"
+)
+
+// BurstStream fires bursts of spikes at a fixed interval within each
+// burst, repeated every period (ms), starting at the offset:
+//
+// offset|x-x-x------------period|x-x-x------------period|x-x-x---
+//
+// A burst of 1 spike is regular periodic spiking.
+type BurstStream struct {
+	basePatternStream
+
+	period   int
+	spikes   int
+	interval int
+	offset   int
+
+	// Time (ms) of the next step.
+	now int
+}
+
+// NewBurstStream creates a stream firing spikes every interval, period
+// apart, from offset. The burst should fit within the period.
+func NewBurstStream(period, spikes, interval, offset int) IPatternStream {
+	s := new(BurstStream)
+	s.baseInitialize()
+	s.period = period
+	s.spikes = spikes
+	s.interval = interval
+	s.offset = offset
+	return s
+}
+
+// NewRegularStream creates a stream firing every period from offset.
+func NewRegularStream(period, offset int) IPatternStream {
+	return NewBurstStream(period, 1, 1, offset)
+}
+
+func (ss *BurstStream) Period() int {
+	return ss.period
+}
+
+func (ss *BurstStream) SetPeriod(v int) {
+	ss.period = v
+}
+
+func (ss *BurstStream) Spikes() int {
+	return ss.spikes
+}
+
+func (ss *BurstStream) SetSpikes(v int) {
+	ss.spikes = v
+}
+
+func (ss *BurstStream) Interval() int {
+	return ss.interval
+}
+
+func (ss *BurstStream) SetInterval(v int) {
+	ss.interval = v
+}
+
+// ----------------------------------------------
+// IPatternStream methods
+// ----------------------------------------------
+
+func (ss *BurstStream) EnableAutoReset() {
+	// Not applicable
+}
+
+// Reset restarts the stream at t = 0.
+func (ss *BurstStream) Reset() {
+	ss.now = 0
+}
+
+func (ss *BurstStream) Step() bool {
+	ss.value = 0
+
+	if t := ss.now - ss.offset; t >= 0 {
+		within := t % ss.period
+		if within%ss.interval == 0 && within/ss.interval < ss.spikes {
+			ss.value = 1
+		}
+	}
+	ss.now++
+
+	// Place stream's current output value onto the
+	// associated connection(s) input
+	it := ss.cons.Iterator()
+	for it.Next() {
+		conn := it.Value().(cell.IConnection)
+		conn.Input(ss.value)
+	}
+
+	return false
+}
+
+func (ss *BurstStream) IsComplete() bool {
+	return false // This type of stream never completes
+}
+
+// ----------------------------------------------
+// IBitStream methods
+// ----------------------------------------------
+
+func (ss *BurstStream) Input(v byte) {
+	// Not applicable.
+}
+
+func (ss *BurstStream) Output() byte {
+	return ss.value
+}
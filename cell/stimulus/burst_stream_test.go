@@ -0,0 +1,75 @@
+package stimulus
+
+import "testing"
+
+func Test_BurstStreamTiming(t *testing.T) {
+	cases := []struct {
+		name                              string
+		period, spikes, interval, offset int
+	}{
+		{"burst", 20, 3, 4, 5},
+		{"burst from 0", 25, 4, 5, 0},
+		{"packed burst", 10, 10, 1, 3},
+		{"regular", 10, 1, 1, 3},
+	}
+
+	for _, c := range cases {
+		s := NewBurstStream(c.period, c.spikes, c.interval, c.offset)
+
+		expected := map[int]bool{}
+		for start := c.offset; start < 500; start += c.period {
+			for k := 0; k < c.spikes; k++ {
+				expected[start+k*c.interval] = true
+			}
+		}
+
+		for now := 0; now < 500; now++ {
+			s.Step()
+			if spike := s.Output() == 1; spike != expected[now] {
+				t.Fatalf("%s: spike %v at %dms, expected %v", c.name, spike, now, expected[now])
+			}
+		}
+	}
+}
+
+func Test_RegularStreamPeriodAndOffset(t *testing.T) {
+	cases := []struct{ period, offset int }{
+		{1, 0},
+		{10, 3},
+		{100, 0},
+		{37, 50},
+	}
+
+	for _, c := range cases {
+		s := NewRegularStream(c.period, c.offset)
+
+		var spikes []int
+		for now := 0; now < 1000; now++ {
+			s.Step()
+			if s.Output() == 1 {
+				spikes = append(spikes, now)
+			}
+		}
+
+		if len(spikes) == 0 || spikes[0] != c.offset {
+			t.Fatalf("period %d offset %d: first spike at %v", c.period, c.offset, spikes)
+		}
+		for i := 1; i < len(spikes); i++ {
+			if spikes[i]-spikes[i-1] != c.period {
+				t.Fatalf("period %d offset %d: ISI %d at %dms", c.period, c.offset, spikes[i]-spikes[i-1], spikes[i])
+			}
+		}
+		if expected := (1000-c.offset-1)/c.period + 1; len(spikes) != expected {
+			t.Fatalf("period %d offset %d: %d spikes, expected %d", c.period, c.offset, len(spikes), expected)
+		}
+
+		// A reset starts over from t = 0.
+		s.Reset()
+		for now := 0; now <= c.offset; now++ {
+			s.Step()
+		}
+		if s.Output() != 1 {
+			t.Fatalf("period %d offset %d: no spike at the offset after a reset", c.period, c.offset)
+		}
+	}
+}
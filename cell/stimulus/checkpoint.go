@@ -0,0 +1,260 @@
+package stimulus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// PoissonState is a PoissonStream's checkpoint, including the
+// position of its random generator.
+type PoissonState struct {
+	ID     int       `json:"id"`
+	Rng    rng.State `json:"rng"`
+	ISI    int       `json:"isi"`
+	Max    float64   `json:"max"`
+	Spread float64   `json:"spread"`
+	Min    float64   `json:"min"`
+	Value  byte      `json:"value"`
+	// The distribution's mean and gamma shape, when there is one.
+	ISIMean  float64 `json:"isiMean,omitempty"`
+	ISIShape float64 `json:"isiShape,omitempty"`
+}
+
+func (ss *PoissonStream) Snapshot() PoissonState {
+	st := PoissonState{
+		ID:     ss.id,
+		Rng:    ss.src.State(),
+		ISI:    ss.isi,
+		Max:    ss.max,
+		Spread: ss.spread,
+		Min:    ss.min,
+		Value:  ss.value,
+	}
+	if ss.distribution != nil {
+		st.ISIMean = ss.distribution.Mean()
+	}
+	if gamma, ok := ss.distribution.(*dist.GammaISI); ok {
+		st.ISIShape = gamma.Shape()
+	}
+	return st
+}
+
+func (ss *PoissonStream) Restore(st PoissonState) {
+	ss.src.Restore(st.Rng)
+	ss.isi = st.ISI
+	ss.max = st.Max
+	ss.spread = st.Spread
+	ss.min = st.Min
+	ss.value = st.Value
+	if ss.distribution != nil && st.ISIMean > 0 {
+		ss.distribution.SetMean(st.ISIMean)
+	}
+	if gamma, ok := ss.distribution.(*dist.GammaISI); ok && st.ISIShape > 0 {
+		gamma.SetShape(st.ISIShape)
+	}
+}
+
+// RateState is a RateStream's checkpoint.
+type RateState struct {
+	ID         int       `json:"id"`
+	Rng        rng.State `json:"rng"`
+	Now        float64   `json:"now"`
+	Candidate  *float64  `json:"candidate,omitempty"` // nil for none
+	DeadUntil  float64   `json:"deadUntil"`
+	Scale      float64   `json:"scale"`
+	Refractory float64   `json:"refractory"`
+	Value      byte      `json:"value"`
+	// The rate's values when they can be changed while running.
+	Oscillation *OscillationRate `json:"oscillation,omitempty"`
+}
+
+func (ss *RateStream) Snapshot() RateState {
+	st := RateState{
+		ID:         ss.id,
+		Rng:        ss.src.State(),
+		Now:        ss.now,
+		DeadUntil:  ss.deadUntil,
+		Scale:      ss.scale,
+		Refractory: ss.refractory,
+		Value:      ss.value,
+	}
+	if !math.IsInf(ss.candidate, 1) {
+		candidate := ss.candidate
+		st.Candidate = &candidate
+	}
+	if osc, ok := ss.rate.(*OscillationRate); ok {
+		rate := *osc
+		st.Oscillation = &rate
+	}
+	return st
+}
+
+func (ss *RateStream) Restore(st RateState) {
+	ss.src.Restore(st.Rng)
+	ss.now = st.Now
+	ss.candidate = math.Inf(1)
+	if st.Candidate != nil {
+		ss.candidate = *st.Candidate
+	}
+	ss.deadUntil = st.DeadUntil
+	ss.scale = st.Scale
+	ss.refractory = st.Refractory
+	ss.value = st.Value
+	if osc, ok := ss.rate.(*OscillationRate); ok && st.Oscillation != nil {
+		*osc = *st.Oscillation
+	}
+}
+
+// BurstState is a BurstStream's checkpoint.
+type BurstState struct {
+	ID       int  `json:"id"`
+	Now      int  `json:"now"`
+	Period   int  `json:"period"`
+	Spikes   int  `json:"spikes"`
+	Interval int  `json:"interval"`
+	Value    byte `json:"value"`
+}
+
+func (ss *BurstStream) Snapshot() BurstState {
+	return BurstState{
+		ID:       ss.id,
+		Now:      ss.now,
+		Period:   ss.period,
+		Spikes:   ss.spikes,
+		Interval: ss.interval,
+		Value:    ss.value,
+	}
+}
+
+func (ss *BurstStream) Restore(st BurstState) {
+	ss.now = st.Now
+	ss.period = st.Period
+	ss.spikes = st.Spikes
+	ss.interval = st.Interval
+	ss.value = st.Value
+}
+
+// SpikeStreamState is a SpikeStream's position in its pattern.
+type SpikeStreamState struct {
+	ID       int  `json:"id"`
+	Idx      int  `json:"idx"`
+	Complete bool `json:"complete"`
+	Value    byte `json:"value"`
+	// The current presentation's spikes, in SetSpikes order, when
+	// they're perturbed.
+	Presented string `json:"presented,omitempty"`
+}
+
+func (ss *SpikeStream) Snapshot() SpikeStreamState {
+	st := SpikeStreamState{ID: ss.id, Idx: ss.idx, Complete: ss.complete, Value: ss.value}
+
+	if !bytes.Equal(ss.pattern, ss.spikes) {
+		for _, spike := range ss.pattern {
+			st.Presented += fmt.Sprintf("%d", spike)
+		}
+	}
+
+	return st
+}
+
+func (ss *SpikeStream) Restore(st SpikeStreamState) {
+	copy(ss.pattern, ss.spikes)
+	for t, c := range st.Presented {
+		if t < len(ss.pattern) {
+			ss.pattern[t] = byte(c - '0')
+		}
+	}
+
+	ss.idx = st.Idx
+	ss.complete = st.Complete
+	ss.value = st.Value
+}
+
+// PatternSetState is a PatternSet's checkpoint.
+type PatternSetState struct {
+	Rng     rng.State `json:"rng"`
+	Index   int       `json:"index"`
+	Current int       `json:"current"`
+}
+
+// PatternState is an NPatternStream's checkpoint.
+type PatternState struct {
+	Schedule ScheduleState      `json:"schedule"`
+	Now      int                `json:"now"`
+	Delay    int                `json:"delay"`
+	Output   byte               `json:"output"`
+	Streams  []SpikeStreamState `json:"streams"`
+
+	Perturbation Perturbation `json:"perturbation"`
+	PerturbRng   *rng.State   `json:"perturbRng,omitempty"`
+
+	Presenting bool             `json:"presenting"`
+	Set        *PatternSetState `json:"set,omitempty"`
+}
+
+func (nps *NPatternStream) Snapshot() PatternState {
+	st := PatternState{
+		Schedule: nps.schedule.Snapshot(),
+		Now:      nps.now,
+		Delay:    nps.delay,
+		Output:   nps.output,
+
+		Perturbation: *nps.perturbation,
+
+		Presenting: nps.presenting,
+	}
+
+	if nps.set != nil {
+		set := nps.set.Snapshot()
+		st.Set = &set
+	}
+
+	if nps.perturbSrc != nil {
+		state := nps.perturbSrc.State()
+		st.PerturbRng = &state
+	}
+
+	it := nps.patterns.Iterator()
+	for it.Next() {
+		stim := it.Value().(*SpikeStream)
+		st.Streams = append(st.Streams, stim.Snapshot())
+	}
+
+	return st
+}
+
+// Restore expects the same streams, in the same order, and the same
+// schedule as the checkpointed stream had.
+func (nps *NPatternStream) Restore(st PatternState) error {
+	if len(st.Streams) != nps.patterns.Size() {
+		return fmt.Errorf("checkpoint has %d pattern streams, expected %d", len(st.Streams), nps.patterns.Size())
+	}
+
+	nps.schedule.Restore(st.Schedule)
+	nps.now = st.Now
+	nps.delay = st.Delay
+	nps.output = st.Output
+	nps.presenting = st.Presenting
+
+	if nps.set != nil && st.Set != nil {
+		nps.set.Restore(*st.Set)
+		nps.setSpikes(nps.set.Current())
+	}
+
+	*nps.perturbation = st.Perturbation
+	if nps.perturbSrc != nil && st.PerturbRng != nil {
+		nps.perturbSrc.Restore(*st.PerturbRng)
+	}
+
+	it := nps.patterns.Iterator()
+	for it.Next() {
+		stim := it.Value().(*SpikeStream)
+		stim.Restore(st.Streams[it.Index()])
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package stimulus
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// Orders in which a PatternSet presents its patterns.
+const (
+	OrderRandom     = "random"
+	OrderRoundRobin = "roundRobin"
+	OrderScripted   = "scripted"
+)
+
+// PatternSet holds labeled patterns that are presented on the same
+// streams, one per presentation, for example to train a neuron to
+// respond to pattern A but not to B.
+type PatternSet struct {
+	labels []string
+	// Per pattern, one spike train per stream in SetSpikes order.
+	patterns [][][]byte
+
+	order string
+	// Pattern indices in presentation order, for OrderScripted.
+	script []int
+	// Position in the round robin or script.
+	idx int
+
+	ran *rand.Rand
+	src *rng.Source
+
+	current int
+}
+
+// NewPatternSet creates an empty set presenting in order, random
+// choices are drawn from src.
+func NewPatternSet(order string, src *rng.Source) *PatternSet {
+	ps := new(PatternSet)
+	ps.order = order
+	ps.src = src
+	ps.ran = rand.New(src)
+	return ps
+}
+
+// Add adds a labeled pattern, one spike train per stream.
+func (ps *PatternSet) Add(label string, streams [][]byte) {
+	ps.labels = append(ps.labels, label)
+	ps.patterns = append(ps.patterns, streams)
+}
+
+// SetScript sets the order of the labels for OrderScripted, it repeats
+// once it's used up.
+func (ps *PatternSet) SetScript(labels []string) error {
+	ps.script = ps.script[:0]
+	for _, label := range labels {
+		i := ps.Find(label)
+		if i < 0 {
+			return fmt.Errorf("no pattern labeled `%s`, available: %s", label, strings.Join(ps.labels, ", "))
+		}
+		ps.script = append(ps.script, i)
+	}
+	return nil
+}
+
+// Find returns the index of a label, or -1.
+func (ps *PatternSet) Find(label string) int {
+	for i, l := range ps.labels {
+		if l == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// Choose picks the next pattern to present and returns its index.
+func (ps *PatternSet) Choose() int {
+	switch ps.order {
+	case OrderRoundRobin:
+		ps.current = ps.idx % len(ps.patterns)
+		ps.idx++
+	case OrderScripted:
+		ps.current = ps.script[ps.idx%len(ps.script)]
+		ps.idx++
+	default:
+		ps.current = ps.ran.Intn(len(ps.patterns))
+	}
+	return ps.current
+}
+
+// Current returns the index of the pattern chosen last.
+func (ps *PatternSet) Current() int {
+	return ps.current
+}
+
+// Pattern returns a pattern's spike trains.
+func (ps *PatternSet) Pattern(i int) [][]byte {
+	return ps.patterns[i]
+}
+
+func (ps *PatternSet) Label(i int) string {
+	return ps.labels[i]
+}
+
+func (ps *PatternSet) Size() int {
+	return len(ps.patterns)
+}
+
+// Reset restarts the order.
+func (ps *PatternSet) Reset() {
+	ps.src.Reset()
+	ps.idx = 0
+}
+
+func (ps *PatternSet) Snapshot() PatternSetState {
+	return PatternSetState{Rng: ps.src.State(), Index: ps.idx, Current: ps.current}
+}
+
+func (ps *PatternSet) Restore(st PatternSetState) {
+	ps.src.Restore(st.Rng)
+	ps.idx = st.Index
+	ps.current = st.Current
+}
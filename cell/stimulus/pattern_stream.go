@@ -2,44 +2,98 @@ package stimulus
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 
 	sll "github.com/emirpasic/gods/lists/singlylinkedlist"
+	"github.com/wdevore/Deuron4/deuron/rng"
 )
 
-// NPatternStream N spike streams.
-// Each stream is routed to 1 or more IConnections.
+// NPatternStream N spike streams presented together as a pattern.
+// Each stream is routed to 1 or more IConnections. The schedule
+// decides when the pattern is presented.
+//
+// ---|--pattern--|----delay----|--pattern--|---delay---|--pattern--|
 type NPatternStream struct {
 	output byte
 
-	complete  bool
-	autoReset bool
+	schedule ISchedule
 
 	// A collection of streams
 	patterns *sll.List
 	patItr   sll.Iterator
-	// period = 1/frequency * 1000 (ms)
-	period int // in milliseconds
 
-	// delay = period - pattern_length
-	delay    int
-	delayCnt int
+	// Time (ms) since the last reset.
+	now int
+	// Time (ms) left before the next presentation, -1 for none.
+	delay int
+	// If a presentation is under way, and if it started this step.
+	presenting bool
+	onset      bool
+
+	// Labeled patterns presented in turn, nil for a single pattern.
+	set *PatternSet
+
+	// Applied to every presentation, drawing from its own generator.
+	perturbation *Perturbation
+	perturbRan   *rand.Rand
+	perturbSrc   *rng.Source
 }
 
-func NewNPatternStream() *NPatternStream {
+// NewNPatternStream creates a pattern stream presented on schedule.
+func NewNPatternStream(schedule ISchedule) *NPatternStream {
 	s := new(NPatternStream)
-	s.autoReset = true
+	s.schedule = schedule
+	s.perturbation = new(Perturbation)
 	s.patterns = sll.New()
 	return s
 }
 
-// Period sets the "delay" between pattern applications. The pattern
-// period itself could be longer that the delay.
-func (nps *NPatternStream) Period(period, patternLen int) {
-	nps.period = period
-	nps.delay = nps.period - patternLen
-	nps.delayCnt = nps.delay
+// SetSchedule changes the schedule, starting with the next
+// presentation.
+func (nps *NPatternStream) SetSchedule(schedule ISchedule) {
+	nps.schedule = schedule
+}
+
+func (nps *NPatternStream) Schedule() ISchedule {
+	return nps.schedule
+}
+
+// SetPerturbation perturbs every following presentation with p, using
+// src for the random choices.
+func (nps *NPatternStream) SetPerturbation(p Perturbation, src *rng.Source) {
+	*nps.perturbation = p
+	nps.perturbSrc = src
+	nps.perturbRan = rand.New(src)
+	nps.Reset()
+}
+
+// Perturbation returns the perturbation, changes to it take effect on
+// the next presentation.
+func (nps *NPatternStream) Perturbation() *Perturbation {
+	return nps.perturbation
+}
+
+// SetPatternSet presents the set's patterns, one per presentation,
+// instead of the streams' own spikes. Every pattern has a spike train
+// per stream.
+func (nps *NPatternStream) SetPatternSet(set *PatternSet) {
+	nps.set = set
+	nps.Reset()
+}
 
+// Label returns the label of the pattern being (or about to be)
+// presented, empty without a pattern set.
+func (nps *NPatternStream) Label() string {
+	if nps.set == nil {
+		return ""
+	}
+	return nps.set.Label(nps.set.Current())
+}
+
+// Onset reports if a presentation started during the last step.
+func (nps *NPatternStream) Onset() bool {
+	return nps.onset
 }
 
 func (nps *NPatternStream) Add(strm IPatternStream) {
@@ -50,37 +104,80 @@ func (nps *NPatternStream) IsComplete() bool {
 	return false
 }
 
-func (nps *NPatternStream) EnableAutoReset() {
-	nps.autoReset = true
+// Reset restarts the schedule and the pattern.
+func (nps *NPatternStream) Reset() {
+	nps.schedule.Reset()
+	if nps.perturbSrc != nil {
+		nps.perturbSrc.Reset()
+	}
+
+	if nps.set != nil {
+		nps.set.Reset()
+	}
+
+	nps.now = 0
+	nps.delay = nps.schedule.Next(nps.now)
+	nps.presenting = false
+	nps.onset = false
+	nps.present()
 }
 
-func (nps *NPatternStream) Reset() {
-	nps.complete = false
-	nps.delayCnt = nps.delay
+// present prepares the streams for the next presentation, choosing
+// the pattern from the set if there is one.
+func (nps *NPatternStream) present() {
+	if nps.set != nil {
+		nps.setSpikes(nps.set.Choose())
+	}
+
 	it := nps.patterns.Iterator()
 	for it.Next() {
+		if spk, ok := it.Value().(*SpikeStream); ok && nps.perturbRan != nil {
+			spk.Present(nps.perturbation, nps.perturbRan)
+			continue
+		}
 		stim := it.Value().(IPatternStream)
 		stim.Reset()
 	}
 }
 
-func (nps *NPatternStream) Step() {
-	// Step all the streams when the delay had ended.
-	// Once the pattern has completed we switch back to delay.
-	if nps.delayCnt < 0 {
-		var complete bool
-		it := nps.patterns.Iterator()
-		for it.Next() {
-			stim := it.Value().(IPatternStream)
-			complete = complete || stim.Step()
+// setSpikes gives the streams the spikes of one of the set's patterns.
+func (nps *NPatternStream) setSpikes(pattern int) {
+	spikes := nps.set.Pattern(pattern)
+	it := nps.patterns.Iterator()
+	for it.Next() {
+		if spk, ok := it.Value().(*SpikeStream); ok && it.Index() < len(spikes) {
+			spk.SetSpikes(spikes[it.Index()])
 		}
+	}
+}
 
-		if complete {
-			// Reset for another delay period
-			nps.Reset()
+func (nps *NPatternStream) Step() {
+	// Step all the streams when the delay has ended. Once the
+	// pattern has completed the schedule gives the next delay.
+	nps.now++
+	nps.onset = false
+
+	if nps.delay != 0 {
+		if nps.delay > 0 {
+			nps.delay--
 		}
-	} else {
-		nps.delayCnt--
+		return
+	}
+
+	nps.onset = !nps.presenting
+	nps.presenting = true
+
+	var complete bool
+	it := nps.patterns.Iterator()
+	for it.Next() {
+		stim := it.Value().(IPatternStream)
+		complete = complete || stim.Step()
+	}
+
+	if complete {
+		nps.presenting = false
+		nps.delay = nps.schedule.Next(nps.now)
+		nps.present()
 	}
 }
 
@@ -104,8 +201,11 @@ func (nps NPatternStream) String() string {
 
 	it := nps.patterns.Iterator()
 	for it.Next() {
-		stim := it.Value().(*SpikeStream)
-		s.WriteString(fmt.Sprintf("%s\n", stim.String()))
+		if stim, ok := it.Value().(fmt.Stringer); ok {
+			s.WriteString(fmt.Sprintf("%s\n", stim.String()))
+		} else {
+			s.WriteString(fmt.Sprintf("stream %d\n", it.Value().(IPatternStream).Id()))
+		}
 	}
 
 	return s.String()
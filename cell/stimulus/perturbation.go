@@ -0,0 +1,57 @@
+package stimulus
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Perturbation changes a pattern every time it is presented, so the
+// neuron never sees exactly the same spikes twice. Spikes are first
+// deleted, then jittered, then extra spikes are inserted.
+type Perturbation struct {
+	// Standard deviation (ms) of each spike's gaussian jitter.
+	Jitter float64 `json:"jitter"`
+	// Probability of deleting each spike.
+	Deletion float64 `json:"deletion"`
+	// Rate (Hz) of extra spikes.
+	Insertion float64 `json:"insertion"`
+}
+
+// IsZero reports if the perturbation leaves patterns unchanged.
+func (p *Perturbation) IsZero() bool {
+	return p.Jitter == 0 && p.Deletion == 0 && p.Insertion == 0
+}
+
+// Apply returns a perturbed copy of pattern. Jittered spikes stay
+// within the pattern and spikes landing on the same ms merge.
+func (p *Perturbation) Apply(pattern []byte, ran *rand.Rand) []byte {
+	perturbed := make([]byte, len(pattern))
+
+	for t, spike := range pattern {
+		if spike == 0 {
+			continue
+		}
+		if p.Deletion > 0 && ran.Float64() < p.Deletion {
+			continue
+		}
+
+		jt := t
+		if p.Jitter > 0 {
+			jt = t + int(math.Round(ran.NormFloat64()*p.Jitter))
+			jt = int(math.Max(0, math.Min(float64(len(pattern)-1), float64(jt))))
+		}
+		perturbed[jt] = 1
+	}
+
+	if p.Insertion > 0 {
+		// Probability of a spike in each 1ms bin.
+		chance := p.Insertion / 1000.0
+		for t := range perturbed {
+			if ran.Float64() < chance {
+				perturbed[t] = 1
+			}
+		}
+	}
+
+	return perturbed
+}
@@ -0,0 +1,111 @@
+package stimulus
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// sparse is a pattern with a spike every 50ms.
+func sparse(length int) []byte {
+	pattern := make([]byte, length)
+	for t := 25; t < length; t += 50 {
+		pattern[t] = 1
+	}
+	return pattern
+}
+
+func count(pattern []byte) int {
+	return bytes.Count(pattern, []byte{1})
+}
+
+func Test_PerturbationJitterBounds(t *testing.T) {
+	cases := []struct{ jitter float64 }{{0.5}, {2}, {5}}
+
+	for _, c := range cases {
+		ran, _ := rng.New(42)
+		p := Perturbation{Jitter: c.jitter}
+		pattern := sparse(1000)
+
+		shifts, total := 0, 0.0
+		for trial := 0; trial < 200; trial++ {
+			perturbed := p.Apply(pattern, ran)
+			if len(perturbed) != len(pattern) || count(perturbed) != count(pattern) {
+				t.Fatalf("jitter %g: %d spikes in %dms, expected %d in %dms",
+					c.jitter, count(perturbed), len(perturbed), count(pattern), len(pattern))
+			}
+
+			// Spikes are 50ms apart so each stays nearest its original.
+			for t0, spike := range pattern {
+				if spike == 0 {
+					continue
+				}
+				for d := -24; d <= 24; d++ {
+					if perturbed[t0+d] == 1 {
+						total += math.Abs(float64(d))
+						shifts++
+					}
+				}
+			}
+		}
+
+		// The mean absolute shift of a gaussian is sd*sqrt(2/pi),
+		// rounding to whole ms adds about 1/(12*sd) for small sd.
+		expected := c.jitter * math.Sqrt(2/math.Pi)
+		if mean := total / float64(shifts); math.Abs(mean-expected) > 0.1*expected+0.1 {
+			t.Errorf("jitter %g: mean shift %.3fms, expected about %.3fms", c.jitter, mean, expected)
+		}
+	}
+
+	// Spikes stay within the pattern.
+	ran, _ := rng.New(1)
+	edges := []byte{1, 0, 0, 0, 0, 0, 0, 1}
+	for trial := 0; trial < 100; trial++ {
+		if perturbed := (&Perturbation{Jitter: 10}).Apply(edges, ran); len(perturbed) != len(edges) || count(perturbed) == 0 {
+			t.Fatalf("jittered edge spikes left the pattern: %v", perturbed)
+		}
+	}
+}
+
+func Test_PerturbationDeletionAndInsertionCounts(t *testing.T) {
+	cases := []struct {
+		name     string
+		p        Perturbation
+		pattern  []byte
+		expected float64
+		// The chances taken per presentation: one per spike for
+		// deletions, one per ms for insertions.
+		chances float64
+	}{
+		{"no change", Perturbation{}, sparse(1000), 20, 20},
+		{"delete 10%", Perturbation{Deletion: 0.1}, sparse(1000), 18, 20},
+		{"delete 50%", Perturbation{Deletion: 0.5}, sparse(1000), 10, 20},
+		{"delete all", Perturbation{Deletion: 1}, sparse(1000), 0, 20},
+		{"insert 20Hz", Perturbation{Insertion: 20}, make([]byte, 1000), 20, 1000},
+		{"insert 5Hz", Perturbation{Insertion: 5}, make([]byte, 1000), 5, 1000},
+	}
+
+	for _, c := range cases {
+		ran, _ := rng.New(7)
+		trials, total := 500, 0
+		for trial := 0; trial < trials; trial++ {
+			total += count(c.p.Apply(c.pattern, ran))
+		}
+
+		// Within 3 standard errors of a binomial count.
+		mean := float64(total) / float64(trials)
+		tolerance := 3*math.Sqrt(c.expected*(1-c.expected/c.chances)/float64(trials)) + 1e-9
+		if math.Abs(mean-c.expected) > tolerance {
+			t.Errorf("%s: %.2f spikes per presentation, expected %g±%.2f", c.name, mean, c.expected, tolerance)
+		}
+
+		// The same seed perturbs the same way.
+		a, _ := rng.New(3)
+		b, _ := rng.New(3)
+		if !bytes.Equal(c.p.Apply(c.pattern, a), c.p.Apply(c.pattern, b)) {
+			t.Errorf("%s: the same seed gave different presentations", c.name)
+		}
+	}
+}
@@ -5,21 +5,18 @@ import (
 	"math/rand"
 
 	"github.com/wdevore/Deuron4/cell"
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
 )
 
-func RanGen(seed int64) *rand.Rand {
-	return rand.New(rand.NewSource(seed))
-}
-
 // PoissonStream generates spikes with poisson distribution.
 // The outputs are generally routed into StraitConnections.
 type PoissonStream struct {
 	basePatternStream
 
 	ran *rand.Rand
-
-	// Random seed
-	seed int64
+	// ran's source. Resets re-seed it with the source's seed.
+	src *rng.Source
 
 	// The Inter spike interval (ISI) counter is populated by a value.
 	// When the counter reaches 0 a spike is placed on the output.
@@ -29,15 +26,19 @@ type PoissonStream struct {
 	max    float64
 	spread float64
 	min    float64
+
+	// Draws the ISIs instead of Generate when set.
+	distribution dist.IDistribution
 }
 
-// NewPoissonStream creates a stream
-func NewPoissonStream(seed int64) IPatternStream {
+// NewPoissonStream creates a stream drawing from src, typically
+// a named stream of an rng.Manager.
+func NewPoissonStream(src *rng.Source) IPatternStream {
 	s := new(PoissonStream)
 	s.baseInitialize()
 
-	s.seed = seed
-	s.ran = RanGen(seed)
+	s.src = src
+	s.ran = rand.New(src)
 
 	s.max = 300.0
 	s.spread = 50.0
@@ -88,6 +89,17 @@ func (ss *PoissonStream) SetSpread(v float64) {
 	ss.spread = v
 }
 
+// SetDistribution draws the ISIs, plus the minimum, from d instead of
+// using Generate. nil restores Generate.
+func (ss *PoissonStream) SetDistribution(d dist.IDistribution) {
+	ss.distribution = d
+	ss.Reset()
+}
+
+func (ss *PoissonStream) Distribution() dist.IDistribution {
+	return ss.distribution
+}
+
 func Generate(rand, scale, div, min float64) int {
 	return int(scale*math.Pow(math.E, -rand*scale/div) + min)
 }
@@ -96,9 +108,18 @@ func Generate(rand, scale, div, min float64) int {
 // Typical values of: 15.0, 3.0 yield ISIs 5-7 with occasional 50-100s,
 // or 50.0,15.0,2.0
 func (ss *PoissonStream) generate(scale, div, min float64) int {
+	if ss.distribution != nil {
+		return countdown(min + ss.distribution.Sample(ss.ran))
+	}
 	return Generate(ss.ran.Float64(), scale, div, min)
 }
 
+// countdown converts an ISI (ms) into the steps to wait after a spike,
+// ISIs shorter than 1ms are 1ms.
+func countdown(isi float64) int {
+	return int(math.Max(1.0, math.Round(isi))) - 1
+}
+
 // ----------------------------------------------
 // IPatternStream methods
 // ----------------------------------------------
@@ -109,7 +130,7 @@ func (ss *PoissonStream) EnableAutoReset() {
 
 // Reset generates a new ISI
 func (ss *PoissonStream) Reset() {
-	ss.ran.Seed(ss.seed)
+	ss.src.Reset()
 	ss.isi = ss.generate(ss.max, ss.spread, ss.min)
 }
 
@@ -0,0 +1,58 @@
+package stimulus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+func Test_PoissonStreamDistributionISI(t *testing.T) {
+	cases := []struct {
+		name string
+		d    dist.IDistribution
+		min  float64
+		cv   float64
+	}{
+		{"exponential", dist.NewExponentialISI(40), 0, 1},
+		{"gamma", dist.NewGammaISI(40, 4), 2, 20.0 / 42.0},
+		{"regular gamma", dist.NewGammaISI(40, 25), 5, 8.0 / 45.0},
+	}
+
+	for i, c := range cases {
+		_, src := rng.New(int64(i + 1))
+		s := NewPoissonStream(src).(*PoissonStream)
+		s.Initialize(300, 50, c.min)
+		s.SetDistribution(c.d)
+
+		isis := []float64{}
+		last := -1
+		for now := 0; now < 2000000; now++ {
+			s.Step()
+			if s.Output() == 1 {
+				if last >= 0 {
+					isis = append(isis, float64(now-last))
+				}
+				last = now
+			}
+		}
+
+		sum, sq := 0.0, 0.0
+		for _, isi := range isis {
+			sum += isi
+			sq += isi * isi
+		}
+		mean := sum / float64(len(isis))
+		cv := math.Sqrt(sq/float64(len(isis))-mean*mean) / mean
+
+		// ISIs are whole ms, rounding and the 1ms floor shift the
+		// exponential's mean a little.
+		if expected := c.min + c.d.Mean(); math.Abs(mean-expected) > 0.02*expected {
+			t.Errorf("%s: mean ISI %.2fms, expected %.2fms", c.name, mean, expected)
+		}
+		if math.Abs(cv-c.cv) > 0.05*c.cv {
+			t.Errorf("%s: ISI CV %.3f, expected %.3f", c.name, cv, c.cv)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package stimulus
+
+import (
+	"math"
+	"sort"
+)
+
+// IRate is a firing rate (Hz) that changes with time (ms).
+type IRate interface {
+	Rate(t float64) float64
+
+	// Max is the highest rate there is, rates are thinned from it.
+	Max() float64
+}
+
+// ConstantRate is a homogeneous rate.
+type ConstantRate struct {
+	Hz float64
+}
+
+func (r *ConstantRate) Rate(t float64) float64 {
+	return r.Hz
+}
+
+func (r *ConstantRate) Max() float64 {
+	return r.Hz
+}
+
+// SineRate modulates a mean rate sinusoidally, for example at theta.
+// Negative rates are 0.
+type SineRate struct {
+	Mean      float64
+	Amplitude float64
+	// Modulation frequency (Hz)
+	Frequency float64
+	// Phase (degrees) at t = 0
+	Phase float64
+}
+
+func (r *SineRate) Rate(t float64) float64 {
+	angle := 2.0*math.Pi*r.Frequency*t/1000.0 + r.Phase*math.Pi/180.0
+	return math.Max(0.0, r.Mean+r.Amplitude*math.Sin(angle))
+}
+
+func (r *SineRate) Max() float64 {
+	return math.Max(0.0, r.Mean+math.Abs(r.Amplitude))
+}
+
+// StepRate changes from one rate to another at a time (ms).
+type StepRate struct {
+	From float64
+	To   float64
+	At   float64
+}
+
+func (r *StepRate) Rate(t float64) float64 {
+	if t < r.At {
+		return r.From
+	}
+	return r.To
+}
+
+func (r *StepRate) Max() float64 {
+	return math.Max(r.From, r.To)
+}
+
+// RampRate changes linearly from one rate to another between Start and
+// End (ms), holding the rates before and after.
+type RampRate struct {
+	From  float64
+	To    float64
+	Start float64
+	End   float64
+}
+
+func (r *RampRate) Rate(t float64) float64 {
+	switch {
+	case t <= r.Start:
+		return r.From
+	case t >= r.End:
+		return r.To
+	}
+	return r.From + (r.To-r.From)*(t-r.Start)/(r.End-r.Start)
+}
+
+func (r *RampRate) Max() float64 {
+	return math.Max(r.From, r.To)
+}
+
+// SampledRate interpolates linearly between rates sampled at
+// increasing times (ms), holding the first and last rates.
+type SampledRate struct {
+	Times []float64
+	Rates []float64
+}
+
+func (r *SampledRate) Rate(t float64) float64 {
+	i := sort.SearchFloat64s(r.Times, t)
+	switch {
+	case i == 0:
+		return r.Rates[0]
+	case i == len(r.Times):
+		return r.Rates[len(r.Rates)-1]
+	}
+	t0, t1 := r.Times[i-1], r.Times[i]
+	return r.Rates[i-1] + (r.Rates[i]-r.Rates[i-1])*(t-t0)/(t1-t0)
+}
+
+func (r *SampledRate) Max() float64 {
+	max := 0.0
+	for _, rate := range r.Rates {
+		max = math.Max(max, rate)
+	}
+	return max
+}
+
+// OscillationRate fires phase locked to a theta oscillation with a
+// gamma oscillation nested in it, used with a RateStream. Each locking
+// is a von Mises concentration around the preferred phase (degrees,
+// where phase 0 is at t = 0): 0 fires at every phase, larger values
+// fire in a narrower range of phases. The mean rate stays Mean (Hz).
+// A frequency of 0 disables an oscillation.
+type OscillationRate struct {
+	Mean float64 `json:"mean"`
+
+	Theta        float64 `json:"theta"`
+	ThetaPhase   float64 `json:"thetaPhase"`
+	ThetaLocking float64 `json:"thetaLocking"`
+
+	Gamma        float64 `json:"gamma"`
+	GammaPhase   float64 `json:"gammaPhase"`
+	GammaLocking float64 `json:"gammaLocking"`
+}
+
+func (r *OscillationRate) Rate(t float64) float64 {
+	return r.Mean *
+		locking(r.Theta, r.ThetaPhase, r.ThetaLocking, t) *
+		locking(r.Gamma, r.GammaPhase, r.GammaLocking, t)
+}
+
+func (r *OscillationRate) Max() float64 {
+	max := r.Mean
+	if r.Theta > 0 {
+		max *= math.Exp(r.ThetaLocking) / besselI0(r.ThetaLocking)
+	}
+	if r.Gamma > 0 {
+		max *= math.Exp(r.GammaLocking) / besselI0(r.GammaLocking)
+	}
+	return max
+}
+
+// locking is a von Mises density relative to uniform, its mean over a
+// cycle is 1.
+func locking(frequency, phase, concentration, t float64) float64 {
+	if frequency <= 0 || concentration == 0 {
+		return 1.0
+	}
+	angle := 2.0*math.Pi*frequency*t/1000.0 - phase*math.Pi/180.0
+	return math.Exp(concentration*math.Cos(angle)) / besselI0(concentration)
+}
+
+// besselI0 is the modified Bessel function of the first kind, order 0.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 100; k++ {
+		term *= (x / 2.0) / float64(k)
+		sum += term * term
+		if term*term < sum*1e-16 {
+			break
+		}
+	}
+	return sum
+}
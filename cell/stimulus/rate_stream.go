@@ -0,0 +1,145 @@
+package stimulus
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/wdevore/Deuron4/cell"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// RateStream is an inhomogeneous Poisson stream whose rate (Hz) is a
+// function of time. Spikes are generated by thinning: candidates are
+// drawn at the highest rate and each is kept with the probability
+// rate(t)/max. After a spike the stream is silent for the absolute
+// refractory period, which lowers the rate at high rates.
+// Spikes falling within the same 1ms step are a single spike.
+type RateStream struct {
+	basePatternStream
+
+	ran *rand.Rand
+	// ran's source. Resets re-seed it with the source's seed.
+	src *rng.Source
+
+	rate IRate
+	// Multiplies the rate.
+	scale float64
+	// Absolute refractory period (ms)
+	refractory float64
+
+	// Time (ms) of the next step.
+	now float64
+	// Time (ms) of the next candidate spike, +Inf for none.
+	candidate float64
+	// No spikes before this time (ms).
+	deadUntil float64
+}
+
+// NewRateStream creates a stream drawing from src, typically a named
+// stream of an rng.Manager.
+func NewRateStream(src *rng.Source, rate IRate, refractory float64) IPatternStream {
+	s := new(RateStream)
+	s.baseInitialize()
+
+	s.src = src
+	s.ran = rand.New(src)
+	s.rate = rate
+	s.scale = 1.0
+	s.refractory = refractory
+
+	s.Reset()
+	return s
+}
+
+// Rate returns the rate function, changes take effect from the next
+// step.
+func (ss *RateStream) Rate() IRate {
+	return ss.rate
+}
+
+func (ss *RateStream) Scale() float64 {
+	return ss.scale
+}
+
+// SetScale scales the rate, the next candidate is re-drawn which is
+// fine as the process has no memory.
+func (ss *RateStream) SetScale(v float64) {
+	ss.scale = v
+	ss.candidate = ss.next(ss.now)
+}
+
+func (ss *RateStream) Refractory() float64 {
+	return ss.refractory
+}
+
+func (ss *RateStream) SetRefractory(v float64) {
+	ss.refractory = v
+}
+
+// next draws the time of the candidate spike after t.
+func (ss *RateStream) next(t float64) float64 {
+	max := ss.rate.Max() * ss.scale
+	if max <= 0 {
+		return math.Inf(1)
+	}
+	return t + ss.ran.ExpFloat64()/max*1000.0
+}
+
+// ----------------------------------------------
+// IPatternStream methods
+// ----------------------------------------------
+
+func (ss *RateStream) EnableAutoReset() {
+	// Not applicable
+}
+
+// Reset restarts the stream at t = 0.
+func (ss *RateStream) Reset() {
+	ss.src.Reset()
+	ss.now = 0
+	ss.deadUntil = 0
+	ss.candidate = ss.next(0)
+}
+
+func (ss *RateStream) Step() bool {
+	ss.value = 0
+
+	// Thin the candidates falling within this step.
+	max := ss.rate.Max() * ss.scale
+	for ss.candidate < ss.now+1 {
+		t := ss.candidate
+		ss.candidate = ss.next(t)
+
+		if t >= ss.deadUntil && ss.ran.Float64()*max < ss.scale*ss.rate.Rate(t) {
+			ss.value = 1
+			ss.deadUntil = t + ss.refractory
+		}
+	}
+	ss.now++
+
+	// Place stream's current output value onto the
+	// associated connection(s) input
+	it := ss.cons.Iterator()
+	for it.Next() {
+		conn := it.Value().(cell.IConnection)
+		conn.Input(ss.value)
+	}
+
+	return false
+}
+
+func (ss *RateStream) IsComplete() bool {
+	return false // This type of stream never completes
+}
+
+// ----------------------------------------------
+// IBitStream methods
+// ----------------------------------------------
+
+func (ss *RateStream) Input(v byte) {
+	// Not applicable.
+}
+
+func (ss *RateStream) Output() byte {
+	return ss.value
+}
@@ -0,0 +1,114 @@
+package stimulus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// windowRates runs one stream per seed for length ms and returns the
+// mean rate (Hz) within each window (ms) of windows.
+func windowRates(rate IRate, refractory float64, runs, length int, windows [][2]int) []float64 {
+	counts := make([]int, len(windows))
+	for run := 0; run < runs; run++ {
+		_, src := rng.New(int64(1000 + run))
+		s := NewRateStream(src, rate, refractory)
+		for now := 0; now < length; now++ {
+			s.Step()
+			if s.Output() == 0 {
+				continue
+			}
+			for i, w := range windows {
+				if now >= w[0] && now < w[1] {
+					counts[i]++
+				}
+			}
+		}
+	}
+
+	hz := make([]float64, len(windows))
+	for i, w := range windows {
+		hz[i] = float64(counts[i]) / (float64(runs) * float64(w[1]-w[0]) / 1000.0)
+	}
+	return hz
+}
+
+func Test_RateStreamFollowsRate(t *testing.T) {
+	cases := []struct {
+		name     string
+		rate     IRate
+		windows  [][2]int
+		expected []float64
+	}{
+		{"constant", &ConstantRate{Hz: 20}, [][2]int{{0, 1000}}, []float64{20}},
+		{"step", &StepRate{From: 5, To: 40, At: 500}, [][2]int{{0, 500}, {500, 1000}}, []float64{5, 40}},
+		{"ramp", &RampRate{From: 0, To: 50, Start: 0, End: 1000}, [][2]int{{0, 1000}, {400, 600}}, []float64{25, 25}},
+		{"sine", &SineRate{Mean: 20, Amplitude: 20, Frequency: 2}, [][2]int{{0, 250}, {250, 500}}, []float64{20 + 40/math.Pi, 20 - 40/math.Pi}},
+		{"samples", &SampledRate{Times: []float64{0, 500}, Rates: []float64{10, 30}}, [][2]int{{0, 500}, {500, 1000}}, []float64{20, 30}},
+	}
+
+	for _, c := range cases {
+		hz := windowRates(c.rate, 0, 400, 1000, c.windows)
+		for i, expected := range c.expected {
+			// The counts are Poisson, allow 4 standard errors.
+			spikes := expected * 400 * float64(c.windows[i][1]-c.windows[i][0]) / 1000.0
+			tolerance := 4 * expected / math.Sqrt(spikes)
+			if math.Abs(hz[i]-expected) > tolerance {
+				t.Errorf("%s: %.2fHz during %v, expected %.2f±%.2f", c.name, hz[i], c.windows[i], expected, tolerance)
+			}
+		}
+	}
+}
+
+func Test_RateStreamRefractory(t *testing.T) {
+	cases := []struct {
+		hz, refractory float64
+	}{
+		{100, 0},
+		{100, 5},
+		{200, 2},
+		{50, 10},
+	}
+
+	for i, c := range cases {
+		_, src := rng.New(int64(i + 1))
+		s := NewRateStream(src, &ConstantRate{Hz: c.hz}, c.refractory)
+
+		spikes, last := 0, -1
+		for now := 0; now < 500000; now++ {
+			s.Step()
+			if s.Output() == 0 {
+				continue
+			}
+			if last >= 0 && float64(now-last) < c.refractory {
+				t.Fatalf("%gHz refractory %gms: spikes %dms apart", c.hz, c.refractory, now-last)
+			}
+			last = now
+			spikes++
+		}
+
+		// A dead time lowers the rate to r/(1 + r*refractory). Spikes
+		// within the same ms merge, which matters without one.
+		expected := c.hz / (1 + c.hz*c.refractory/1000.0)
+		if c.refractory == 0 {
+			expected = 1000.0 * (1 - math.Exp(-c.hz/1000.0))
+		}
+		if hz := float64(spikes) / 500.0; math.Abs(hz-expected) > 0.03*expected {
+			t.Errorf("%gHz refractory %gms: %.2fHz, expected %.2fHz", c.hz, c.refractory, hz, expected)
+		}
+	}
+}
+
+func Test_RateStreamScale(t *testing.T) {
+	_, src := rng.New(5)
+	s := NewRateStream(src, &ConstantRate{Hz: 10}, 0).(*RateStream)
+	s.SetScale(0)
+
+	for now := 0; now < 10000; now++ {
+		s.Step()
+		if s.Output() == 1 {
+			t.Fatalf("spike at %dms with the rate scaled to 0", now)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package stimulus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// besselI1 is the modified Bessel function of the first kind, order 1.
+func besselI1(x float64) float64 {
+	sum, term := 0.0, x/2.0
+	for k := 0; k < 100; k++ {
+		sum += term
+		term *= (x / 2.0) * (x / 2.0) / float64((k+1)*(k+2))
+	}
+	return sum
+}
+
+func Test_OscillationPhaseLocking(t *testing.T) {
+	cases := []struct {
+		name      string
+		rate      OscillationRate
+		frequency float64
+		phase     float64
+		kappa     float64
+	}{
+		{"theta", OscillationRate{Mean: 10, Theta: 8, ThetaPhase: 180, ThetaLocking: 2}, 8, 180, 2},
+		{"weak theta", OscillationRate{Mean: 10, Theta: 6, ThetaPhase: 90, ThetaLocking: 0.5}, 6, 90, 0.5},
+		{"gamma", OscillationRate{Mean: 10, Gamma: 40, GammaPhase: 270, GammaLocking: 1}, 40, 270, 1},
+	}
+
+	for i, c := range cases {
+		_, src := rng.New(int64(100 + i))
+		rate := c.rate
+		s := NewRateStream(src, &rate, 0)
+
+		spikes := 0
+		cx, cy := 0.0, 0.0
+		for now := 0; now < 1000000; now++ {
+			s.Step()
+			if s.Output() == 1 {
+				// Spikes are anywhere within the 1ms step.
+				angle := 2.0 * math.Pi * c.frequency * (float64(now) + 0.5) / 1000.0
+				cx += math.Cos(angle)
+				cy += math.Sin(angle)
+				spikes++
+			}
+		}
+
+		mean := math.Mod(math.Atan2(cy, cx)*180.0/math.Pi+360.0, 360.0)
+		if d := math.Abs(mean - c.phase); math.Min(d, 360.0-d) > 5.0 {
+			t.Errorf("%s: mean phase %.1f, expected %.1f", c.name, mean, c.phase)
+		}
+
+		// The resultant length of a von Mises distribution.
+		expected := besselI1(c.kappa) / besselI0(c.kappa)
+		if r := math.Hypot(cx, cy) / float64(spikes); math.Abs(r-expected) > 0.03 {
+			t.Errorf("%s: concentration %.3f, expected %.3f for kappa %g", c.name, r, expected, c.kappa)
+		}
+
+		if hz := float64(spikes) / 1000.0; math.Abs(hz-c.rate.Mean) > 0.5 {
+			t.Errorf("%s: mean rate %.2fHz, expected %gHz", c.name, hz, c.rate.Mean)
+		}
+	}
+}
+
+func Test_OscillationMaxBoundsRate(t *testing.T) {
+	r := &OscillationRate{Mean: 10, Theta: 8, ThetaPhase: 45, ThetaLocking: 3, Gamma: 40, GammaPhase: 10, GammaLocking: 1.5}
+	max := r.Max()
+	for t0 := 0.0; t0 < 1000.0; t0 += 0.1 {
+		if rate := r.Rate(t0); rate > max*(1+1e-9) {
+			t.Fatalf("rate %f at %fms exceeds the max %f", rate, t0, max)
+		}
+	}
+}
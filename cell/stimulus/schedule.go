@@ -0,0 +1,206 @@
+package stimulus
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// ISchedule decides when a pattern container presents its pattern.
+type ISchedule interface {
+	// Next returns the delay (ms) before the next presentation starts,
+	// or -1 for no more presentations. now is the time (ms) since the
+	// last Reset.
+	Next(now int) int
+
+	// Reset restarts the schedule, including any random generator.
+	Reset()
+
+	Snapshot() ScheduleState
+	Restore(ScheduleState)
+}
+
+// ScheduleState is a schedule's checkpoint.
+type ScheduleState struct {
+	Rng   *rng.State `json:"rng,omitempty"`
+	Index int        `json:"index,omitempty"`
+}
+
+// -----------------------------------------------------------------
+// Fixed period
+// -----------------------------------------------------------------
+
+// FixedSchedule presents at 0, period, 2*period... A presentation
+// longer than the period skips the onsets it overlaps.
+type FixedSchedule struct {
+	period int
+}
+
+func NewFixedSchedule(period int) *FixedSchedule {
+	s := new(FixedSchedule)
+	s.period = period
+	return s
+}
+
+func (s *FixedSchedule) Next(now int) int {
+	return (s.period - now%s.period) % s.period
+}
+
+func (s *FixedSchedule) Reset() {
+}
+
+func (s *FixedSchedule) Snapshot() ScheduleState {
+	return ScheduleState{}
+}
+
+func (s *FixedSchedule) Restore(st ScheduleState) {
+}
+
+// -----------------------------------------------------------------
+// Poisson ISI
+// -----------------------------------------------------------------
+
+// PoissonSchedule waits a Poisson ISI after each presentation, see
+// Generate, or min plus an ISI from a distribution.
+type PoissonSchedule struct {
+	ran *rand.Rand
+	src *rng.Source
+
+	max    float64
+	spread float64
+	min    float64
+
+	distribution dist.IDistribution
+}
+
+// NewPoissonSchedule creates a schedule drawing its ISIs from src.
+func NewPoissonSchedule(src *rng.Source, max, spread, min float64) *PoissonSchedule {
+	s := new(PoissonSchedule)
+	s.src = src
+	s.ran = rand.New(src)
+	s.max = max
+	s.spread = spread
+	s.min = min
+	return s
+}
+
+// SetDistribution draws the ISIs from d instead of using Generate.
+func (s *PoissonSchedule) SetDistribution(d dist.IDistribution) {
+	s.distribution = d
+}
+
+func (s *PoissonSchedule) Next(now int) int {
+	if s.distribution != nil {
+		return int(math.Round(s.min + s.distribution.Sample(s.ran)))
+	}
+	return Generate(s.ran.Float64(), s.max, s.spread, s.min)
+}
+
+func (s *PoissonSchedule) Reset() {
+	s.src.Reset()
+}
+
+func (s *PoissonSchedule) Snapshot() ScheduleState {
+	st := s.src.State()
+	return ScheduleState{Rng: &st}
+}
+
+func (s *PoissonSchedule) Restore(st ScheduleState) {
+	if st.Rng != nil {
+		s.src.Restore(*st.Rng)
+	}
+}
+
+// -----------------------------------------------------------------
+// Explicit times
+// -----------------------------------------------------------------
+
+// ListSchedule presents at the given times (ms) after each reset. An
+// onset that falls during a presentation is presented as soon as the
+// presentation completes.
+type ListSchedule struct {
+	times []int
+	idx   int
+}
+
+func NewListSchedule(times []int) *ListSchedule {
+	s := new(ListSchedule)
+	s.times = append([]int{}, times...)
+	sort.Ints(s.times)
+	return s
+}
+
+func (s *ListSchedule) Next(now int) int {
+	if s.idx >= len(s.times) {
+		return -1
+	}
+	onset := s.times[s.idx]
+	s.idx++
+
+	if onset < now {
+		return 0
+	}
+	return onset - now
+}
+
+func (s *ListSchedule) Reset() {
+	s.idx = 0
+}
+
+func (s *ListSchedule) Snapshot() ScheduleState {
+	return ScheduleState{Index: s.idx}
+}
+
+func (s *ListSchedule) Restore(st ScheduleState) {
+	s.idx = st.Index
+}
+
+// -----------------------------------------------------------------
+// Gamma ISI
+// -----------------------------------------------------------------
+
+// GammaSchedule waits min plus a gamma distributed ISI after each
+// presentation. A shape of 1 is exponential (Poisson), larger shapes
+// are increasingly regular.
+type GammaSchedule struct {
+	ran *rand.Rand
+	src *rng.Source
+
+	// Mean of the gamma part (ms).
+	mean  float64
+	shape float64
+	min   float64
+}
+
+// NewGammaSchedule creates a schedule drawing its ISIs from src.
+func NewGammaSchedule(src *rng.Source, mean, shape, min float64) *GammaSchedule {
+	s := new(GammaSchedule)
+	s.src = src
+	s.ran = rand.New(src)
+	s.mean = mean
+	s.shape = shape
+	s.min = min
+	return s
+}
+
+func (s *GammaSchedule) Next(now int) int {
+	return int(math.Round(s.min + dist.Gamma(s.ran, s.shape, s.mean/s.shape)))
+}
+
+func (s *GammaSchedule) Reset() {
+	s.src.Reset()
+}
+
+func (s *GammaSchedule) Snapshot() ScheduleState {
+	st := s.src.State()
+	return ScheduleState{Rng: &st}
+}
+
+func (s *GammaSchedule) Restore(st ScheduleState) {
+	if st.Rng != nil {
+		s.src.Restore(*st.Rng)
+	}
+}
@@ -0,0 +1,100 @@
+package stimulus
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// onsets presents patterns length ms long on schedule s until horizon
+// and returns the onset times.
+func onsets(s ISchedule, length, horizon int) []int {
+	times := []int{}
+	now := 0
+	for {
+		delay := s.Next(now)
+		if delay < 0 {
+			return times
+		}
+		now += delay
+		if now >= horizon {
+			return times
+		}
+		times = append(times, now)
+		now += length
+	}
+}
+
+func Test_ScheduleMeanISI(t *testing.T) {
+	source := func(seed int64) *rng.Source {
+		_, src := rng.New(seed)
+		return src
+	}
+
+	// Generate's mean over a uniform draw.
+	generated := 0.0
+	for i := 0; i < 100000; i++ {
+		generated += float64(Generate((float64(i)+0.5)/100000, 300, 50, 50))
+	}
+	generated /= 100000
+
+	withDistribution := NewPoissonSchedule(source(4), 300, 50, 20)
+	withDistribution.SetDistribution(dist.NewExponentialISI(80))
+
+	cases := []struct {
+		name     string
+		schedule ISchedule
+		// The mean time (ms) between the end of a presentation and
+		// the next onset.
+		expected  float64
+		tolerance float64
+	}{
+		{"fixed", NewFixedSchedule(100), 100 - 25, 0},
+		{"poisson", NewPoissonSchedule(source(1), 300, 50, 50), generated, 3},
+		{"poisson distribution", withDistribution, 20 + 80, 2},
+		{"gamma", NewGammaSchedule(source(2), 200, 4, 20), 20 + 200, 3},
+		{"gamma shape 1", NewGammaSchedule(source(3), 50, 1, 0), 50, 1.5},
+	}
+
+	for _, c := range cases {
+		times := onsets(c.schedule, 25, 2000000)
+		if len(times) < 2 {
+			t.Fatalf("%s: only %d presentations", c.name, len(times))
+		}
+
+		// Every onset follows a presentation, except the first.
+		mean := float64(times[len(times)-1]-times[0])/float64(len(times)-1) - 25
+		if math.Abs(mean-c.expected) > c.tolerance {
+			t.Errorf("%s: mean ISI %.2fms, expected %.2f±%g", c.name, mean, c.expected, c.tolerance)
+		}
+
+		// A reset replays the same onsets.
+		c.schedule.Reset()
+		if again := onsets(c.schedule, 25, 2000000); !reflect.DeepEqual(times, again) {
+			t.Errorf("%s: the onsets differ after a reset", c.name)
+		}
+	}
+}
+
+func Test_FixedAndListOnsets(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule ISchedule
+		length   int
+		expected []int
+	}{
+		{"fixed", NewFixedSchedule(100), 25, []int{0, 100, 200, 300, 400, 500, 600, 700, 800, 900}},
+		{"fixed skips overlapped onsets", NewFixedSchedule(100), 150, []int{0, 200, 400, 600, 800}},
+		{"list", NewListSchedule([]int{600, 10, 250}), 25, []int{10, 250, 600}},
+		{"list onset during a presentation", NewListSchedule([]int{10, 14, 250}), 25, []int{10, 35, 250}},
+	}
+
+	for _, c := range cases {
+		if times := onsets(c.schedule, c.length, 1000); !reflect.DeepEqual(times, c.expected) {
+			t.Errorf("%s: onsets %v, expected %v", c.name, times, c.expected)
+		}
+	}
+}
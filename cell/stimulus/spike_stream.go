@@ -2,6 +2,7 @@ package stimulus
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 
 	"github.com/wdevore/Deuron4/cell"
@@ -25,6 +26,9 @@ type SpikeStream struct {
 	autoReset bool
 
 	// Spike pattern. The pattern is fixed in size, for now.
+	spikes []byte
+	// The spikes of the current presentation, a perturbed copy of
+	// spikes, see Present.
 	pattern []byte
 	idx     int
 }
@@ -89,54 +93,64 @@ func (ss *SpikeStream) Step() bool {
 }
 
 func (ss *SpikeStream) Set(t int) {
-	if t > len(ss.pattern) {
+	if t >= len(ss.spikes) {
 		fmt.Println("SpikeStream: bad t position")
 		return
 	}
 
+	ss.spikes[t] = 1
 	ss.pattern[t] = 1
 }
 
 func (ss *SpikeStream) SetRange(ts []int) {
 	for _, ti := range ts {
 
-		if ti > len(ss.pattern) {
+		if ti >= len(ss.spikes) {
 			fmt.Println("SpikeStream: bad t position")
 			return
 		}
 
+		ss.spikes[ti] = 1
 		ss.pattern[ti] = 1
 	}
 }
 
 func (ss *SpikeStream) SetSpikes(sp []byte) {
-	ss.pattern = make([]byte, len(sp))
-
-	for t, spik := range sp {
+	ss.spikes = make([]byte, len(sp))
+	copy(ss.spikes, sp)
 
-		if t > len(ss.pattern) {
-			fmt.Println("SpikeStream: bad set position")
-			return
-		}
+	ss.pattern = make([]byte, len(sp))
+	copy(ss.pattern, sp)
 
-		ss.pattern[t] = spik
-	}
 	ss.Reset()
 }
 
 func (ss *SpikeStream) Clear(t int) {
-	if t > len(ss.pattern) {
+	if t >= len(ss.spikes) {
 		fmt.Println("SpikeStream: bad clear position")
 		return
 	}
+	ss.spikes[t] = 0
 	ss.pattern[t] = 0
 }
 
+// Present prepares the next presentation, perturbing the spikes with p
+// unless it's nil or zero.
+func (ss *SpikeStream) Present(p *Perturbation, ran *rand.Rand) {
+	if p == nil || p.IsZero() {
+		copy(ss.pattern, ss.spikes)
+	} else {
+		ss.pattern = p.Apply(ss.spikes, ran)
+	}
+	ss.Reset()
+}
+
+// String returns the unperturbed spikes in time order.
 func (ss SpikeStream) String() string {
 	var s strings.Builder
 
-	for j := len(ss.pattern) - 1; j >= 0; j-- {
-		s.WriteString(fmt.Sprintf("%d", ss.pattern[j]))
+	for j := len(ss.spikes) - 1; j >= 0; j-- {
+		s.WriteString(fmt.Sprintf("%d", ss.spikes[j]))
 	}
 
 	return s.String()
@@ -0,0 +1,211 @@
+package main
+
+/*
+	headless runs a simulation without the GUI, as fast as possible.
+	It doesn't depend on SDL so it builds and runs on compute servers:
+
+	>go build ./cmd/headless
+	>./headless -def runreset.json -cycles 100 -out results
+
+	Output (in the -out directory):
+		definition.json    the definition that was run
+		spikes.csv         every spike: cycle,time,source,id
+		presentations.csv  every pattern presentation: cycle,time,label
+		cycles.csv         per cycle counts and rates
+		summary.json       totals and averages for the run
+
+	Experiments can be branched from a trained state:
+
+	>./headless -cycles 1000 -save trained.json
+	>./headless -restore trained.json -cycles 100 -out branch1
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/samples"
+
+	// Simulation types register themselves.
+	_ "github.com/wdevore/Deuron4/simulation/continuous"
+	_ "github.com/wdevore/Deuron4/simulation/runreset"
+)
+
+// Summary is written to summary.json at the end of a run.
+type Summary struct {
+	Type       string  `json:"type"`
+	Definition string  `json:"definition"`
+	Checkpoint string  `json:"checkpoint,omitempty"`
+	Steps      int     `json:"steps"`
+	Cycles     int     `json:"cycles"`
+	Elapsed    float64 `json:"elapsedSeconds"`
+	StepsPerS  float64 `json:"stepsPerSecond"`
+	NoiseRate  float64 `json:"noiseRateHz"`
+	StimRate   float64 `json:"stimulusRateHz"`
+	OutputRate float64 `json:"outputRateHz"`
+	OutSpikes  int     `json:"outputSpikes"`
+	// The seed of every random stream, for reproducing the run.
+	Seeds map[string]int64 `json:"seeds"`
+}
+
+func main() {
+	simType := flag.String("type", "runreset", fmt.Sprintf("simulation type %v", simulation.Names()))
+	defPath := flag.String("def", "", "json simulation definition, defaults are used if empty")
+	steps := flag.Int("steps", 0, "number of steps (ms) to run")
+	cycles := flag.Int("cycles", 1, "number of run-reset cycles (or windows) to run, ignored if -steps is set")
+	outDir := flag.String("out", "out", "directory for the results")
+	restore := flag.String("restore", "", "checkpoint to continue from, -def is ignored")
+	save := flag.String("save", "", "checkpoint to write at the end of the run")
+	flag.Parse()
+
+	sim, err := simulation.New(*simType)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *defPath != "" && *restore == "" {
+		if err = sim.Load(*defPath); err != nil {
+			log.Fatalf("%s:\n%v", *defPath, err)
+		}
+	}
+
+	// Nothing listens in headless mode so just drain the channels.
+	statusComm := make(chan simulation.Status)
+	propEventComm := make(chan simulation.PropertyChange)
+	go func() {
+		for range statusComm {
+		}
+	}()
+	go func() {
+		for range propEventComm {
+		}
+	}()
+	sim.Connect(statusComm, propEventComm)
+
+	if *restore != "" {
+		err = sim.Restore(*restore)
+	} else {
+		err = sim.Create()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.MkdirAll(*outDir, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, _ := json.MarshalIndent(sim.Definition(), "", "  ")
+	err = ioutil.WriteFile(filepath.Join(*outDir, "definition.json"), data, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	spikesF, err := os.Create(filepath.Join(*outDir, "spikes.csv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer spikesF.Close()
+	spikes := bufio.NewWriter(spikesF)
+	defer spikes.Flush()
+	fmt.Fprintln(spikes, "cycle,time,source,id")
+
+	presentF, err := os.Create(filepath.Join(*outDir, "presentations.csv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer presentF.Close()
+	presentations := bufio.NewWriter(presentF)
+	defer presentations.Flush()
+	fmt.Fprintln(presentations, "cycle,time,label")
+
+	cyclesF, err := os.Create(filepath.Join(*outDir, "cycles.csv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cyclesF.Close()
+	cycleW := bufio.NewWriter(cyclesF)
+	defer cycleW.Flush()
+	fmt.Fprintln(cycleW, "cycle,noiseSpikes,stimSpikes,outputSpikes,noiseRateHz,stimRateHz,outputRateHz")
+
+	summary := Summary{Type: *simType, Definition: *defPath, Checkpoint: *restore}
+	start := time.Now()
+
+	for {
+		if *steps > 0 && summary.Steps >= *steps {
+			break
+		}
+		if *steps == 0 && summary.Cycles >= *cycles {
+			break
+		}
+
+		complete := sim.Tick()
+		summary.Steps++
+
+		if complete {
+			// A completed cycle is always published.
+			writeCycle(spikes, presentations, cycleW, sim.Samples().Acquire(), summary.Cycles, &summary)
+			summary.Cycles++
+		}
+	}
+
+	summary.Elapsed = time.Since(start).Seconds()
+	if summary.Elapsed > 0 {
+		summary.StepsPerS = float64(summary.Steps) / summary.Elapsed
+	}
+	if summary.Cycles > 0 {
+		summary.NoiseRate /= float64(summary.Cycles)
+		summary.StimRate /= float64(summary.Cycles)
+		summary.OutputRate /= float64(summary.Cycles)
+	}
+
+	summary.Seeds = sim.Seeds()
+
+	if *save != "" {
+		if err = sim.Save(*save); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	data, _ = json.MarshalIndent(summary, "", "  ")
+	err = ioutil.WriteFile(filepath.Join(*outDir, "summary.json"), data, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d steps, %d cycles in %0.2fs. Results in `%s`\n", summary.Steps, summary.Cycles, summary.Elapsed, *outDir)
+}
+
+// writeCycle records a completed cycle's samples. The rates are
+// accumulated into the summary and averaged at the end.
+func writeCycle(spikes, presentations, cycles *bufio.Writer, frame *samples.Frame, cycle int, summary *Summary) {
+	poi := frame.Poi
+	stim := frame.Stim
+	cell := frame.Cell
+
+	poi.WriteSpikes(spikes, cycle, "poisson")
+	stim.WriteSpikes(spikes, cycle, "stimulus")
+	cell.WriteSpikes(spikes, cycle, "neuron")
+
+	for _, p := range frame.Presentations {
+		fmt.Fprintf(presentations, "%d,%d,%s\n", cycle, int(p.Time), p.Label)
+	}
+
+	fmt.Fprintf(cycles, "%d,%d,%d,%d,%f,%f,%f\n", cycle,
+		poi.SpikeCount(), stim.SpikeCount(), cell.SpikeCount(),
+		poi.Rate(), stim.Rate(), cell.Rate())
+
+	summary.NoiseRate += poi.Rate()
+	summary.StimRate += stim.Rate()
+	summary.OutputRate += cell.Rate()
+	summary.OutSpikes += cell.SpikeCount()
+}
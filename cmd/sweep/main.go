@@ -0,0 +1,62 @@
+package main
+
+/*
+	sweep runs a simulation over ranges of property values, in
+	parallel, and writes a results table:
+
+	>go build ./cmd/sweep
+	>./sweep -spec sweep.json -out results.csv
+
+	See sweep.json for an example spec.
+*/
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/wdevore/Deuron4/simulation/sweep"
+
+	// Simulation types register themselves.
+	_ "github.com/wdevore/Deuron4/simulation/continuous"
+	_ "github.com/wdevore/Deuron4/simulation/runreset"
+)
+
+func main() {
+	specPath := flag.String("spec", "sweep.json", "json sweep spec")
+	outPath := flag.String("out", "sweep.csv", "results table")
+	workers := flag.Int("workers", -1, "configurations run in parallel, overrides the spec if >= 0")
+	flag.Parse()
+
+	spec, err := sweep.LoadSpec(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *workers >= 0 {
+		spec.Workers = *workers
+	}
+
+	out, err := os.Create(*outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	start := time.Now()
+
+	results := sweep.Run(spec, func(done, total int, r *sweep.Result) {
+		if r.Err != nil {
+			fmt.Printf("[%d/%d] run %d %v: %v\n", done, total, r.Run, r.Values, r.Err)
+			return
+		}
+		fmt.Printf("[%d/%d] run %d %v: output %0.2fHz\n", done, total, r.Run, r.Values, r.OutputRate)
+	})
+
+	if err = sweep.WriteCSV(out, spec, results); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d configurations in %0.2fs. Results in `%s`\n", len(results), time.Since(start).Seconds(), *outPath)
+}
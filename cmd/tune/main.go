@@ -0,0 +1,67 @@
+package main
+
+/*
+	tune adjusts properties until a simulation's metrics reach their
+	targets, logging every evaluation:
+
+	>go build ./cmd/tune
+	>./tune -spec tune.json -out trajectory.csv
+
+	See tune.json for an example spec. Output targets (outputRate,
+	cvISI, patternRatio) need a neuron model that fires, tuning fails
+	if a target's metric never changes.
+*/
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/wdevore/Deuron4/simulation/tune"
+
+	// Simulation types register themselves.
+	_ "github.com/wdevore/Deuron4/simulation/continuous"
+	_ "github.com/wdevore/Deuron4/simulation/runreset"
+)
+
+func main() {
+	specPath := flag.String("spec", "tune.json", "json tuning spec")
+	outPath := flag.String("out", "trajectory.csv", "every evaluation's values and metrics")
+	flag.Parse()
+
+	spec, err := tune.LoadSpec(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out, err := os.Create(*outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	start := time.Now()
+
+	tuner := tune.NewTuner(spec, func(step *tune.Step) {
+		fmt.Println(step)
+	})
+
+	best, err := tuner.Run()
+
+	if werr := tune.WriteTrajectory(out, spec, tuner.Trajectory); werr != nil {
+		log.Fatal(werr)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d evaluations in %0.2fs. Trajectory in `%s`\n", len(tuner.Trajectory), time.Since(start).Seconds(), *outPath)
+	if best.Met {
+		fmt.Printf("Targets met by %v\n", best.Values)
+	} else {
+		fmt.Printf("Targets not met, closest %v\n", best)
+	}
+}
@@ -1,16 +1,24 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/wdevore/Deuron4/deuron/app/graphs"
-	"github.com/wdevore/Deuron4/simulation/runreset"
-	"github.com/wdevore/Deuron4/simulation/samples"
+	"github.com/wdevore/Deuron4/deuron/console"
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/tune"
+
+	// Simulation types register themselves.
+	_ "github.com/wdevore/Deuron4/simulation/continuous"
+	_ "github.com/wdevore/Deuron4/simulation/runreset"
 )
 
 const (
@@ -18,6 +26,9 @@ const (
 	height = 1024
 	xpos   = 100
 	ypos   = 0
+
+	// How long to wait for the sim to reply to a command.
+	requestTimeout = time.Second * 5
 )
 
 // App shows the plots and graphs.
@@ -47,14 +58,27 @@ type App struct {
 	pointsGraph graphs.IGraph
 	expoGraph   graphs.IGraph
 
-	simType string // "runreset" or "continous"
+	simType string // "runreset" or "continuous", see simulation.Names()
 	target  string // Path to simulation
 
 	// comm channel to simulation
-	statusComm    chan string
-	propEventComm chan string
-	requestComm   chan string
-	runResetSim   *runreset.RunResetSim
+	statusComm    chan simulation.Status
+	propEventComm chan simulation.PropertyChange
+	// Commands, the render loop and the pollers use the sim, see
+	// current.
+	sim      simulation.ISimulation
+	simMutex sync.Mutex
+	// Stops the pollers of the connected sim.
+	stopPolling context.CancelFunc
+	// Only one connect at a time.
+	connectMutex sync.Mutex
+
+	// Requests waiting on a reply, by Command ID.
+	pending      map[int64]chan simulation.Status
+	pendingMutex sync.Mutex
+
+	// The type the sim was created as.
+	simConType string
 
 	// Commands
 	thing    string
@@ -68,6 +92,11 @@ type App struct {
 
 	incSize float64
 	decSize float64
+
+	shutdownOnce sync.Once
+
+	// The App's own properties, the sim has its own.
+	properties *property.Registry
 }
 
 // NewApp creates a new App and initializes it.
@@ -79,9 +108,23 @@ func NewApp() *App {
 	v.mode = "Main"
 	v.incSize = 10.0
 	v.decSize = 10.0
+	v.pending = make(map[int64]chan simulation.Status)
 	v.keyMaps = make([]IKeyMap, 10)
 	v.keyMaps[0] = NewKeyMap0(v)
 	v.keyMaps[1] = NewKeyMap1(v)
+	v.keyMaps[2] = NewKeyMap2(v)
+
+	v.properties = property.NewRegistry()
+	v.properties.Register(&property.Property{
+		Name: "Inc Size", Min: 0.01, Max: 1000.0, Step: 1.0, Default: v.incSize,
+		Get: func() float64 { return v.incSize },
+		Set: func(value float64) { v.incSize = value },
+	})
+	v.properties.Register(&property.Property{
+		Name: "Dec Size", Min: 0.01, Max: 1000.0, Step: 1.0, Default: v.decSize,
+		Get: func() float64 { return v.decSize },
+		Set: func(value float64) { v.decSize = value },
+	})
 
 	return v
 }
@@ -105,121 +148,69 @@ func (v *App) SetText(field, value string) {
 	v.txtActiveProperty.SetName(field + ": ")
 }
 
+// SetAppCommand sets an App property given as <property...> <value>,
+// for example: ExpoFunc Tau 50
 func (v *App) SetAppCommand(cmd []string) {
-	// process command for app
-	prop := cmd[0]
-
-	switch prop {
-	case "ExpoFunc":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		sub := cmd[1]
-		switch sub {
-		case "A":
-			val := cmd[2]
-			fval, _ := strconv.ParseFloat(val, 64)
-			graph.SetA(fval)
-			break
-		case "Tau":
-			val := cmd[2]
-			fval, _ := strconv.ParseFloat(val, 64)
-			graph.SetTau(fval)
-			break
-		case "M":
-			val := cmd[2]
-			fval, _ := strconv.ParseFloat(val, 64)
-			graph.SetM(fval)
-			break
-		case "WMax":
-			val := cmd[2]
-			fval, _ := strconv.ParseFloat(val, 64)
-			graph.SetWMax(fval)
-			break
-		}
-		break
+	if err := v.setAppProperty(cmd); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (v *App) setAppProperty(cmd []string) error {
+	name, text, err := property.Split(cmd)
+	if err != nil {
+		return err
+	}
+
+	p, err := v.properties.Find(name)
+	if err != nil {
+		return err
 	}
+
+	value, err := p.Parse(text)
+	if err != nil {
+		return err
+	}
+
+	return v.properties.Set(name, value)
 }
 
+// IncrementAppProperty moves an App property, given as
+// <property...> <value>, up by the increment size.
 func (v *App) IncrementAppProperty(cmd []string) float64 {
-	prop := cmd[0]
-
-	switch prop {
-	case "ExpoFunc":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		what := cmd[1]
-		switch what {
-		case "A":
-			val := graph.A()
-			graph.SetA(val + v.incSize)
-			return graph.A()
-		case "Tau":
-			val := graph.Tau()
-			graph.SetTau(val + v.incSize)
-			return graph.Tau()
-		case "M":
-			val := graph.M()
-			graph.SetM(val + v.incSize)
-			return graph.M()
-		case "WMax":
-			val := graph.WMax()
-			graph.SetWMax(val + v.incSize)
-			return graph.WMax()
-		}
-		break
+	name, _, err := property.Split(cmd)
+	if err != nil {
+		return 0
 	}
 
-	return 0
+	value, _ := v.properties.Nudge(name, v.incSize)
+	return value
 }
 
+// DecrementAppProperty moves an App property down by the decrement size.
 func (v *App) DecrementAppProperty(cmd []string) {
-	prop := cmd[0]
-
-	switch prop {
-	case "ExpoFunc":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		what := cmd[1]
-		switch what {
-		case "A":
-			val := graph.A()
-			graph.SetA(val - v.decSize)
-			break
-		case "Tau":
-			val := graph.Tau()
-			graph.SetTau(val - v.decSize)
-			break
-		case "M":
-			val := graph.M()
-			graph.SetM(val - v.decSize)
-			break
-		case "WMax":
-			val := graph.WMax()
-			graph.SetWMax(val - v.decSize)
-			break
-		}
-		break
+	name, _, err := property.Split(cmd)
+	if err != nil {
+		return
 	}
+
+	v.properties.Nudge(name, -v.decSize)
 }
 
-func (v *App) RequestAppProperty(property string) string {
-	switch property {
-	case "ExpoFunc A":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		return fmt.Sprintf("%0.4f", graph.A())
-	case "ExpoFunc Tau":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		return fmt.Sprintf("%0.4f", graph.Tau())
-	case "ExpoFunc M":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		return fmt.Sprintf("%0.4f", graph.M())
-	case "ExpoFunc WMax":
-		graph := v.expoGraph.(*graphs.ExpoGraph)
-		return fmt.Sprintf("%0.4f", graph.WMax())
+// RequestAppProperty returns an App property's formatted value.
+func (v *App) RequestAppProperty(name string) string {
+	p, err := v.properties.Find(name)
+	if err != nil {
+		return "??"
 	}
 
-	return "??"
+	return p.Format(p.Get())
 }
 
 func (v *App) SetCommand(cmd []string) {
-	v.runResetSim.SetCommand(cmd)
+	if sim := v.current(); sim != nil {
+		sim.SetCommand(cmd)
+	}
 }
 
 func (v *App) SetValue(value string) {
@@ -258,7 +249,10 @@ func (v *App) Run() {
 		// v.pointsGraph.MarkDirty(true)
 		v.spikeGraph.MarkDirty(true)
 
-		if samples.PoiSamples != nil {
+		// Render the latest frame the sim has published, the sim
+		// keeps running while we draw.
+		if sim := v.current(); sim != nil && sim.Samples() != nil {
+			v.spikeGraph.(*graphs.SpikesGraph).SetFrame(sim.Samples().Acquire())
 			draw := v.spikeGraph.Check()
 			if draw {
 				v.spikeGraph.DrawAt(0, 100)
@@ -357,6 +351,7 @@ func (v *App) initialize() {
 	v.spikeGraph = graphs.NewSpikesGraph(v.renderer, v.texture, 1024, 300)
 
 	v.expoGraph = graphs.NewExpoGraph(v.renderer, v.texture, 1024, 600)
+	v.expoGraph.(*graphs.ExpoGraph).RegisterProperties(v.properties)
 }
 
 // Configure view with draw objects
@@ -376,86 +371,205 @@ func (v *App) Configure() {
 	// v.dynaTxt = NewDynaText(v.nFont, v.renderer)
 }
 
-// Command handles messages from the console.
-func (v *App) Command(args []string) {
+// Command handles messages from the key maps.
+func (v *App) Command(args []string) (string, error) {
+	return v.CommandFrom(simulation.SourceKey, args)
+}
+
+// CommandFrom handles messages from the console and key maps, source
+// is recorded with any property change. The reply and error are
+// returned to the console.
+func (v *App) CommandFrom(source string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command")
+	}
+
 	switch args[0] {
 	case "quit":
 		v.shutdown()
+		return "Shutting down", nil
 	case "set":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: set sim-name")
+		}
 		v.target = args[1]
+		return fmt.Sprintf("Target set to `%s`", v.target), nil
 	case "type":
+		if len(args) < 2 {
+			return v.simType, nil
+		}
+		if !simulation.Has(args[1]) {
+			return "", fmt.Errorf("unknown type `%s`, available: %v", args[1], simulation.Names())
+		}
 		v.simType = args[1]
 		fmt.Printf("Type switched to `%s`\n", v.simType)
+		return fmt.Sprintf("Type switched to `%s`", v.simType), nil
 	case "con":
-		v.connect()
-	case "ping":
-		v.runResetSim.Send("ping")
-	case "start":
-		v.start()
+		if v.connect() == nil {
+			return "", fmt.Errorf("unable to connect to `%s`", v.simType)
+		}
+		return fmt.Sprintf("Connected to `%s`", v.simConType), nil
 	case "go":
 		go v.doit()
-	case "stop":
-		if v.runResetSim == nil {
-			panic("Not connected. Please connect first. Use 'help'.")
+		return "Going", nil
+	case "create":
+		if err := v.create(); err != nil {
+			return "", err
 		}
-		v.runResetSim.Send("stop")
-		response := <-v.statusComm
-		if response == "Stopped" {
-			fmt.Println("Sim requested to stop")
+		return "Created", nil
+	case "preset":
+		return v.preset(source, args)
+	case "props":
+		if len(args) > 1 {
+			name := strings.Join(args[1:], " ")
+			if v.properties.Has(name) {
+				return v.properties.Elements(name)
+			}
+			break
 		}
-		break
+		list := v.properties.List()
+		if v.current() != nil {
+			status := v.request(simulation.NewCommand("props"))
+			if status.Err == nil {
+				list += "\n" + status.Message
+			}
+		}
+		return list, nil
 	case "prop":
-		// A command relating to a property
-		v.runResetSim.Command(args)
-		break
-	case "step":
-		v.runResetSim.Step()
-		break
-	case "runPause":
-		v.runResetSim.RunPause()
-		break
-	case "reset":
-		v.runResetSim.Reset()
-		break
-	case "create":
-		v.create()
-		break
+		// The App's own properties don't need a sim.
+		name, _, err := property.Split(args[1:])
+		if err == nil && v.properties.Has(name) {
+			if err = v.setAppProperty(args[1:]); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s = %s", name, v.RequestAppProperty(name)), nil
+		}
 	}
-}
 
-func (v *App) create() {
-	// Connect
-	v.connect()
+	// The remaining commands require a connected sim.
+	if v.current() == nil {
+		return "", fmt.Errorf("not connected. Please connect first. Use 'help'")
+	}
 
-	// Load
-	v.runResetSim.Send("load")
-	response := <-v.statusComm // wait for response
+	switch args[0] {
+	case "ping", "start", "stop", "pause", "resume", "step", "runPause", "reset", "state", "speed", "seed", "save", "restore",
+		"prop", "props", "undo", "redo", "history", "patterns", "pattern":
+		// These go to the sim.
+		status := v.request(simulation.NewCommand(args[0], args[1:]...).From(source))
+		if status.Err != nil {
+			return "", status.Err
+		}
+		return status.String(), nil
+	case "tune":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: tune spec-file")
+		}
+		spec, err := tune.LoadSpec(args[1])
+		if err != nil {
+			return "", err
+		}
+		go v.tune(spec)
+		return fmt.Sprintf("Tuning %v, see the log", spec.Targets), nil
+	}
+
+	return "", fmt.Errorf("unknown command `%s`", args[0])
+}
 
-	if response != "loaded" {
-		panic("Unable to load parameters")
+// RegisterCommands makes the App's commands available on a console.
+func (v *App) RegisterCommands(c *console.Console) {
+	commands := [][]string{
+		{"quit", "quit", "stops any simulation and exits app."},
+		{"set", "set sim-name", "sets the target simulation, where sim-name specifies a json file in the working directory."},
+		{"type", "type [sim-type]", fmt.Sprintf("changes sim type: %v", simulation.Names())},
+		{"con", "con", "connects to a target sim-name. It does NOT start it."},
+		{"ping", "ping", "sends `ping` to target sim."},
+		{"create", "create", "connects, loads and creates the sim without running it."},
+		{"go", "go", "connects, loads and runs sim."},
+		{"start", "start", "starts the current target simulation."},
+		{"stop", "stop", "stops the current target simulation."},
+		{"pause", "pause", "pauses a running simulation, or resumes a paused one."},
+		{"resume", "resume", "resumes a paused simulation."},
+		{"step", "step", "makes a single step in a paused or stopped simulation."},
+		{"runPause", "runPause", "runs a single pass through a complete simulation then pauses."},
+		{"reset", "reset", "resets the simulation."},
+		{"state", "state", "shows the simulation's run state."},
+		{"speed", "speed [max|real|faster|slower|<scale>x]", "shows or sets the time scale, e.g. `speed 0.5x`. real is 1ms of sim time per ms."},
+		{"seed", "seed [stream seed]", "lists the random stream seeds or re-seeds a stream, e.g. `seed noise 42` re-seeds all noise streams."},
+		{"save", "save file", "writes a checkpoint of the simulation, it can be running."},
+		{"restore", "restore file", "re-creates a stopped simulation from a checkpoint."},
+		{"prop", "prop <property>[@selector] <value>", "changes a property, for example: prop Poisson Min 7.0. Noise streams are selected by ID, exc, inh or tag: prop Poisson Max@0-3,inh 100. `prop up|down [amount]` nudges the last one."},
+		{"preset", "preset list|save|diff|apply|delete [name]", "saves the App's and sim's properties under a name (in presets.json), shows how one differs from the current values or applies it. Presets can be named by their number in the list. Key map 2 manages them too."},
+		{"undo", "undo", "reverts the last property change."},
+		{"redo", "redo", "re-applies the last undone property change."},
+		{"history", "history [count]", "lists the property changes with when, where from and the values before and after."},
+		{"props", "props [property[@selector]]", "lists the App's and the sim's properties with their values, units and ranges, or a property's value per stream."},
+		{"patterns", "patterns", "lists the pattern library, the patterns directory in the working directory."},
+		{"pattern", "pattern save name | pattern generate name spec-file", "saves the definition's pattern into the library, or generates patterns into it, see pattern.json. A definition uses one with \"pattern\": {\"file\": \"name\"}."},
+		{"tune", "tune spec-file", "tunes properties until the spec's targets are met, then applies them to the sim. See tune.json. Output targets (outputRate, cvISI, patternRatio) need a neuron model that fires."},
 	}
 
-	fmt.Println("Loaded")
+	for _, cmd := range commands {
+		c.Register(cmd[0], cmd[1], cmd[2], v.CommandFrom)
+	}
+}
 
-	v.runResetSim.Create()
+// tune runs a tuning spec on its own simulations, logging the
+// trajectory, then applies the best values to the connected sim.
+func (v *App) tune(spec *tune.Spec) {
+	tuner := tune.NewTuner(spec, func(step *tune.Step) {
+		fmt.Printf("Tune %s\n", step)
+	})
 
-	go v.pollForMessage()
-	go v.pollForPropertyEvents()
+	best, err := tuner.Run()
+	if err != nil {
+		// Nothing is applied, the best values may be meaningless.
+		fmt.Printf("Tuning failed: %v\n", err)
+		return
+	}
+	if best == nil {
+		return
+	}
+
+	if best.Met {
+		fmt.Printf("Targets met by %v\n", best.Values)
+	} else {
+		fmt.Printf("Targets not met, applying closest %s\n", best)
+	}
+
+	for i, p := range spec.Parameters {
+		args := append(strings.Fields(p.Property), strconv.FormatFloat(best.Values[i], 'g', -1, 64))
+		if status := v.request(simulation.NewCommand("prop", args...).From(simulation.SourceOptimizer)); status.Err != nil {
+			fmt.Printf("Unable to apply %s: %v\n", p.Property, status.Err)
+		}
+	}
 }
 
-func (v *App) doit() {
+func (v *App) create() error {
 	// Connect
-	v.connect()
+	sim := v.connect()
+	if sim == nil {
+		return fmt.Errorf("unable to connect to `%s`", v.simType)
+	}
+
+	// Load the target's json, if one was set.
+	err := v.load()
+	if err != nil {
+		return err
+	}
 
-	// Load
-	v.runResetSim.Send("load")
-	response := <-v.statusComm // wait for response
+	return sim.Create()
+}
 
-	if response != "loaded" {
-		panic("Unable to load parameters")
+func (v *App) doit() {
+	// Connect
+	if v.connect() == nil {
+		return
 	}
 
-	fmt.Println("Loaded")
+	// Load the target's json, if one was set.
+	if err := v.load(); err != nil {
+		return
+	}
 
 	// Run
 	v.status = "Starting..."
@@ -465,90 +579,202 @@ func (v *App) doit() {
 	v.start()
 }
 
-func (v *App) start() {
-	go v.pollForMessage()
-	go v.pollForPropertyEvents()
+func (v *App) load() error {
+	status := v.request(simulation.NewCommand("load", v.target))
 
+	if status.State != simulation.StatusLoaded {
+		fmt.Printf("Unable to load parameters: %s\n", status)
+		return fmt.Errorf("unable to load parameters: %s", status.Message)
+	}
+
+	fmt.Println("Loaded")
+	return nil
+}
+
+func (v *App) start() simulation.Status {
 	// This will cause the sim to start the simulation in a coroutine.
-	v.runResetSim.Send("start")
 	// We start async because we can't lock the app thread
 	// from receiveing system events (ex: keyboard)
+	return v.request(simulation.NewCommand("start"))
+}
+
+// request sends a command to the sim and waits for the reply
+// carrying the command's ID.
+func (v *App) request(cmd simulation.Command) simulation.Status {
+	reply := make(chan simulation.Status, 1)
+
+	v.pendingMutex.Lock()
+	v.pending[cmd.ID] = reply
+	v.pendingMutex.Unlock()
+
+	defer func() {
+		v.pendingMutex.Lock()
+		delete(v.pending, cmd.ID)
+		v.pendingMutex.Unlock()
+	}()
+
+	sim := v.current()
+	if sim == nil {
+		return cmd.Fail(fmt.Errorf("not connected"))
+	}
+	sim.Command(cmd)
+
+	select {
+	case status := <-reply:
+		return status
+	case <-time.After(requestTimeout):
+		err := fmt.Errorf("no reply to `%s` within %v", cmd.Name, requestTimeout)
+		return cmd.Fail(err)
+	}
 }
 
-// Runs in a coroutine.
-func (v *App) pollForMessage() {
-	var response string
+// Runs in a coroutine for as long as the sim is connected, until ctx
+// is cancelled. Replies are routed to whoever is waiting on them,
+// everything else is shown as the sim's status.
+func (v *App) pollForMessage(ctx context.Context, statusComm chan simulation.Status) {
 	fmt.Print("Waiting for messages from sim...\n")
 
-	for response != "Stopped" {
-		response = <-v.statusComm // Wait for response
+	for {
+		var status simulation.Status
+		select {
+		case <-ctx.Done():
+			fmt.Println("Polling exited")
+			return
+		case status = <-statusComm:
+		}
 
-		v.status = response
+		if status.ID != 0 {
+			v.pendingMutex.Lock()
+			reply, waiting := v.pending[status.ID]
+			v.pendingMutex.Unlock()
+
+			if waiting {
+				reply <- status
+				continue
+			}
+		}
+
+		v.status = status.String()
 		msg := "Status: " + v.status
 		v.txtSimStatus.SetText(msg, sdl.Color{R: 255, G: 127, B: 0, A: 255})
 	}
-
-	fmt.Printf("Polling exited from: (%s)\n", response)
 }
 
-func (v *App) pollForPropertyEvents() {
-	var response string
+func (v *App) pollForPropertyEvents(ctx context.Context, propEventComm chan simulation.PropertyChange) {
 	fmt.Print("Waiting for property events from sim...\n")
 
 	for {
-		response = <-v.propEventComm // Wait for response
-		split := strings.Split(response, ",")
-		fmt.Printf("poll prop: %s, %v\n", response, split)
-		v.SetText(split[0], split[1])
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-propEventComm:
+			fmt.Printf("poll prop: (%d) %s = %s\n", change.ID, change.Property, change.Value)
+			v.SetText(change.Property, change.Value)
+		}
 	}
-
 }
 
 func (v *App) RequestProperty(property string) string {
-	switch property {
-	case "Inc Size":
-		return fmt.Sprintf("%0.2f", v.incSize)
-	case "Dec Size":
-		return fmt.Sprintf("%0.2f", v.decSize)
+	if v.properties.Has(property) {
+		return v.RequestAppProperty(property)
+	}
+
+	sim := v.current()
+	if sim == nil {
+		return "--"
 	}
 
-	return v.runResetSim.RequestProperty(property)
+	return sim.RequestProperty(property)
 }
 
-func (v *App) connect() {
+// current returns the connected sim, nil if there is none.
+func (v *App) current() simulation.ISimulation {
+	v.simMutex.Lock()
+	defer v.simMutex.Unlock()
+	return v.sim
+}
+
+// connect connects to a sim of the current type, replacing a sim of
+// another type, and returns it. It returns nil if the sim can't be
+// created.
+func (v *App) connect() simulation.ISimulation {
+	v.connectMutex.Lock()
+	defer v.connectMutex.Unlock()
+
 	fmt.Printf("Connecting to `%s`...\n", v.simType)
 
-	if v.runResetSim == nil {
-		fmt.Println("Creating sim")
-		v.runResetSim = runreset.NewRunResetSim()
+	sim := v.current()
+	if sim != nil && v.simConType == v.simType {
+		return sim
+	}
 
-		fmt.Println("Creating comm channels")
+	if sim != nil {
+		fmt.Printf("Disconnecting from `%s`\n", v.simConType)
+		v.request(simulation.NewCommand("stop"))
+		v.disconnect()
+	}
 
-		v.statusComm = make(chan string)
-		v.propEventComm = make(chan string)
-		v.requestComm = make(chan string)
-		v.runResetSim.Connect(v.statusComm, v.propEventComm, v.requestComm)
+	fmt.Println("Creating sim")
+	sim, err := simulation.New(v.simType)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
 
-		// Test connection to sim
-		v.runResetSim.Send("ping")
-		response := <-v.statusComm
-		if response == "pong" {
-			fmt.Println("Connected.")
-		} else {
-			fmt.Printf("Sim didn't respond to connection correctly (%s)\n", response)
-		}
+	fmt.Println("Creating comm channels")
+
+	v.statusComm = make(chan simulation.Status)
+	// Buffered as the sim drops events it can't send right away.
+	v.propEventComm = make(chan simulation.PropertyChange, 64)
+	sim.Connect(v.statusComm, v.propEventComm)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go v.pollForMessage(ctx, v.statusComm)
+	go v.pollForPropertyEvents(ctx, v.propEventComm)
+
+	v.simMutex.Lock()
+	v.sim = sim
+	v.simConType = v.simType
+	v.stopPolling = cancel
+	v.simMutex.Unlock()
+
+	// Test connection to sim
+	status := v.request(simulation.NewCommand("ping"))
+	if status.State == simulation.StatusPong {
+		fmt.Println("Connected.")
+	} else {
+		fmt.Printf("Sim didn't respond to connection correctly (%s)\n", status)
 	}
+
+	return sim
 }
 
-func (v *App) shutdown() {
-	fmt.Println("Shutting down...")
+// disconnect forgets the sim and stops its pollers.
+func (v *App) disconnect() {
+	v.simMutex.Lock()
+	defer v.simMutex.Unlock()
 
-	if v.runResetSim != nil {
-		fmt.Println("Sending simulation the `stop` command...")
-		v.runResetSim.Send("stop")
+	v.sim = nil
+	if v.stopPolling != nil {
+		v.stopPolling()
+		v.stopPolling = nil
 	}
+}
+
+// shutdown stops any simulation and quits. Both quit and the end of
+// Run call it, only the first call does anything.
+func (v *App) shutdown() {
+	v.shutdownOnce.Do(func() {
+		fmt.Println("Shutting down...")
+
+		if v.current() != nil {
+			fmt.Println("Sending simulation the `stop` command...")
+			v.request(simulation.NewCommand("stop"))
+			v.disconnect()
+		}
 
-	v.Quit()
+		v.Quit()
 
-	fmt.Println("Done.")
+		fmt.Println("Done.")
+	})
 }
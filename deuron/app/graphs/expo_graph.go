@@ -7,6 +7,7 @@ import (
 
 	"github.com/fogleman/gg"
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/wdevore/Deuron4/simulation/property"
 )
 
 // A test graph for plotting exponentials to see how they shape
@@ -135,6 +136,14 @@ func (g *ExpoGraph) DrawAt(x, y int32) {
 
 }
 
+// RegisterProperties makes the curve's parameters adjustable.
+func (g *ExpoGraph) RegisterProperties(r *property.Registry) {
+	r.Register(&property.Property{Name: "ExpoFunc A", Min: 0.0, Max: 1000.0, Step: 10.0, Default: g.a, Get: g.A, Set: g.SetA})
+	r.Register(&property.Property{Name: "ExpoFunc Tau", Units: "ms", Min: 1.0, Max: 1000.0, Step: 10.0, Default: g.tau, Get: g.Tau, Set: g.SetTau})
+	r.Register(&property.Property{Name: "ExpoFunc M", Min: 0.0, Max: 100.0, Step: 1.0, Default: g.m, Get: g.M, Set: g.SetM})
+	r.Register(&property.Property{Name: "ExpoFunc WMax", Min: 0.0, Max: 100.0, Step: 1.0, Default: g.wMax, Get: g.WMax, Set: g.SetWMax})
+}
+
 func (g *ExpoGraph) Check() bool {
 	return false
 }
@@ -3,15 +3,16 @@ package graphs
 import (
 	"image"
 	"image/color"
-	"math/rand"
 
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/wdevore/Deuron4/deuron/rng"
 )
 
 // SeriesAccessor provides access to series data
 type SeriesAccessor func() (x, y float64, c color.Color, state int)
 
-var ran = rand.New(rand.NewSource(1963))
+// Demo data comes from the default seed manager.
+var ran = rng.Default.Rand("graphs")
 
 type IGraph interface {
 	Destroy()
@@ -23,6 +23,9 @@ type SpikesGraph struct {
 	dc     *gg.Context
 	pixels *image.RGBA
 
+	// The frame being rendered, acquired from the sim's sample buffer.
+	frame *samples.Frame
+
 	originX float64
 	originY float64
 
@@ -34,15 +37,19 @@ type SpikesGraph struct {
 	poisLane    *samples.SamplesLane
 	poisIt      sll.Iterator
 	poisScanIdx int
+	poisOrigin  int
 
 	stimLaneY   float64
 	stimLane    *samples.SamplesLane
 	stimIt      sll.Iterator
 	stimScanIdx int
+	stimOrigin  int
 
 	noiseColor    color.RGBA
 	stimulusColor color.RGBA
 	unknownColor  color.RGBA
+	changeColor   color.RGBA
+	presentColor  color.RGBA
 
 	// Synapse accessor state vars
 	exciteColor  color.RGBA
@@ -71,6 +78,8 @@ func NewSpikesGraph(renderer *sdl.Renderer, texture *sdl.Texture, width, height
 	g.unknownColor = color.RGBA{255, 0, 0, 255}
 	g.noiseColor = color.RGBA{255, 127, 0, 255}
 	g.stimulusColor = color.RGBA{127, 255, 127, 255}
+	g.changeColor = color.RGBA{255, 255, 0, 160}
+	g.presentColor = color.RGBA{0, 255, 255, 255}
 
 	return g
 }
@@ -113,6 +122,26 @@ func (g *SpikesGraph) DrawAt(x, y int32) {
 		g.dc.LineTo(float64(g.rect.W)-g.originX*2, -1.0)
 		g.dc.Stroke()
 
+		// Mark property changes with vertical lines.
+		if g.frame != nil {
+			g.dc.SetColor(g.changeColor)
+			size := g.frame.Poi.Size()
+			origin := g.frame.Poi.Origin()
+			for _, change := range g.frame.Changes {
+				x := float64((int(change.Time)%size - origin + size) % size)
+				g.dc.MoveTo(x, 0.0)
+				g.dc.LineTo(x, float64(g.rect.H)-g.originY*2)
+				g.dc.Stroke()
+			}
+
+			// Label each pattern presentation at its onset.
+			g.dc.SetColor(g.presentColor)
+			for _, p := range g.frame.Presentations {
+				x := float64((int(p.Time)%size - origin + size) % size)
+				g.dc.DrawString(p.Label, x, g.originY)
+			}
+		}
+
 		// Draw colored horizontal bars based on the synapse type.
 
 		// Draw noise spikes.
@@ -152,14 +181,28 @@ func (g *SpikesGraph) DrawAt(x, y int32) {
 
 }
 
+// SetFrame sets the samples to render. The frame must not change
+// until the next SetFrame.
+func (g *SpikesGraph) SetFrame(frame *samples.Frame) {
+	g.frame = frame
+}
+
 func (g *SpikesGraph) Check() bool {
-	poiLanes := samples.PoiSamples.GetLanes()
+	if g.frame == nil {
+		return false
+	}
+
+	// Scrolling samples begin rendering at the oldest sample.
+	g.poisOrigin = g.frame.Poi.Origin()
+	g.stimOrigin = g.frame.Stim.Origin()
+
+	poiLanes := g.frame.Poi.GetLanes()
 	g.poisIt = poiLanes.Iterator()
 
 	if g.poisIt.First() {
 		g.poisLane = g.poisIt.Value().(*samples.SamplesLane)
 
-		stimLanes := samples.StimSamples.GetLanes()
+		stimLanes := g.frame.Stim.GetLanes()
 		g.stimIt = stimLanes.Iterator()
 
 		if g.stimIt.First() {
@@ -174,7 +217,7 @@ func (g *SpikesGraph) Check() bool {
 }
 
 func (g *SpikesGraph) poissonAccessor() (x, y float64, c color.Color, more int) {
-	if g.poisScanIdx >= samples.PoiSamples.Size() {
+	if g.poisScanIdx >= g.frame.Poi.Size() {
 		g.poisScanIdx = 0
 		if !g.poisIt.Next() {
 			g.poisLaneY = 0
@@ -185,7 +228,7 @@ func (g *SpikesGraph) poissonAccessor() (x, y float64, c color.Color, more int)
 		g.poisLane = g.poisIt.Value().(*samples.SamplesLane)
 	}
 
-	spike := g.poisLane.Samples[g.poisScanIdx]
+	spike := g.poisLane.Samples[(g.poisOrigin+g.poisScanIdx)%g.frame.Poi.Size()]
 
 	if spike.Value == 1 {
 		g.state = 1
@@ -193,12 +236,13 @@ func (g *SpikesGraph) poissonAccessor() (x, y float64, c color.Color, more int)
 		g.state = 2
 	}
 
+	x = float64(g.poisScanIdx)
 	g.poisScanIdx++
-	return spike.Time, g.poisLaneY, g.noiseColor, g.state
+	return x, g.poisLaneY, g.noiseColor, g.state
 }
 
 func (g *SpikesGraph) stimAccessor() (x, y float64, c color.Color, more int) {
-	if g.stimScanIdx >= samples.StimSamples.Size() {
+	if g.stimScanIdx >= g.frame.Stim.Size() {
 		g.stimScanIdx = 0
 		if !g.stimIt.Next() {
 			g.stimLaneY = 0
@@ -209,7 +253,7 @@ func (g *SpikesGraph) stimAccessor() (x, y float64, c color.Color, more int) {
 		g.stimLane = g.stimIt.Value().(*samples.SamplesLane)
 	}
 
-	spike := g.stimLane.Samples[g.stimScanIdx]
+	spike := g.stimLane.Samples[(g.stimOrigin+g.stimScanIdx)%g.frame.Stim.Size()]
 	// fmt.Printf("s: %v\n", spike)
 
 	if spike.Value == 1 {
@@ -218,6 +262,7 @@ func (g *SpikesGraph) stimAccessor() (x, y float64, c color.Color, more int) {
 		g.state = 2
 	}
 
+	x = float64(g.stimScanIdx)
 	g.stimScanIdx++
-	return spike.Time, g.stimLaneY, g.stimulusColor, g.state
+	return x, g.stimLaneY, g.stimulusColor, g.state
 }
@@ -26,10 +26,20 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 		return km.mode
 	}
 
+	// While a value is typed the speed and undo keys are part of it.
+	if km.entering {
+		switch code {
+		case sdl.SCANCODE_MINUS, sdl.SCANCODE_EQUALS, sdl.SCANCODE_R, sdl.SCANCODE_M, sdl.SCANCODE_Z, sdl.SCANCODE_X:
+			km.enter(code)
+			return km.mode
+		}
+	}
+
 	switch code {
 	case sdl.SCANCODE_Q:
 		km.property = "Poisson"
 		km.field = "Max"
+		km.entering = true
 		fmt.Println("Enter poisson max value")
 		v := km.App.RequestProperty(km.property + " " + km.field)
 		km.App.SetText(km.property+" "+km.field, v)
@@ -38,6 +48,7 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 	case sdl.SCANCODE_W:
 		km.property = "Poisson"
 		km.field = "Min"
+		km.entering = true
 		fmt.Println("Enter poisson min value")
 		v := km.App.RequestProperty(km.property + " " + km.field)
 		km.App.SetText(km.property+" "+km.field, v)
@@ -46,6 +57,7 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 	case sdl.SCANCODE_E:
 		km.field = "Spread"
 		km.property = "Poisson"
+		km.entering = true
 		fmt.Println("Enter poisson spread value")
 		v := km.App.RequestProperty(km.property + " " + km.field)
 		km.App.SetText(km.property+" "+km.field, v)
@@ -54,6 +66,7 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 	case sdl.SCANCODE_T:
 		km.property = "Inc"
 		km.field = "Size"
+		km.entering = true
 		fmt.Println("Enter Increment size value")
 		v := km.App.RequestProperty(km.property + " " + km.field)
 		km.App.SetText(km.property+" "+km.field, v)
@@ -61,6 +74,7 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 	case sdl.SCANCODE_Y:
 		km.property = "Dec"
 		km.field = "Size"
+		km.entering = true
 		fmt.Println("Enter Decrement size value")
 		v := km.App.RequestProperty(km.property + " " + km.field)
 		km.App.SetText(km.property+" "+km.field, v)
@@ -101,17 +115,36 @@ func (km *keyMap0) handle(code sdl.Scancode, mode string) string {
 		cmd := []string{"pause"}
 		km.App.Command(cmd)
 		break
+	case sdl.SCANCODE_MINUS:
+		km.App.Command([]string{"speed", "slower"})
+		return "handled"
+	case sdl.SCANCODE_EQUALS:
+		km.App.Command([]string{"speed", "faster"})
+		return "handled"
+	case sdl.SCANCODE_R:
+		// Watch in real-time
+		km.App.Command([]string{"speed", "real"})
+		return "handled"
+	case sdl.SCANCODE_M:
+		// Fast forward
+		km.App.Command([]string{"speed", "max"})
+		return "handled"
+	case sdl.SCANCODE_Z:
+		km.App.Command([]string{"undo"})
+		return "handled"
+	case sdl.SCANCODE_X:
+		km.App.Command([]string{"redo"})
+		return "handled"
 	case sdl.SCANCODE_RETURN:
 		fmt.Printf("Entered: (%s), changing back to main.\n", km.value)
 		// Send property to sim.
 		cmd := []string{"prop", km.property, km.field, km.value}
 		km.App.Command(cmd)
 
+		km.entering = false
 		km.mode = "Main"
 	default:
-		km.value = km.value + codeToString(code)
-		// Update gui
-		km.App.SetValue(km.value)
+		km.enter(code)
 
 		// km.App.SetText(km.property+" "+km.field, km.value)
 		break
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// -----------------------------------------------------------------
+// Key map 2, presets
+// -----------------------------------------------------------------
+
+type keyMap2 struct {
+	keyMapBase
+
+	// The preset action waiting for a preset number.
+	action string
+}
+
+func NewKeyMap2(App *App) IKeyMap {
+	km := new(keyMap2)
+	km.App = App
+	return km
+}
+
+func (km *keyMap2) handle(code sdl.Scancode, mode string) string {
+	kmo := km.keyMapBase.handle(code, mode)
+	if kmo == "Main" || kmo == "handled" {
+		return km.mode
+	}
+
+	switch code {
+	case sdl.SCANCODE_L:
+		reply, err := km.App.Command([]string{"preset", "list"})
+		km.show("list", reply, err)
+		km.mode = "Main"
+	case sdl.SCANCODE_A:
+		km.action = "apply"
+		fmt.Println("Enter the slot number of the preset to apply")
+		km.App.SetText("Apply preset", "")
+	case sdl.SCANCODE_D:
+		km.action = "diff"
+		fmt.Println("Enter the slot number of the preset to compare")
+		km.App.SetText("Diff preset", "")
+	case sdl.SCANCODE_S:
+		km.action = "save"
+		fmt.Println("Enter a slot number to save the current values as")
+		km.App.SetText("Save preset slot", "")
+	case sdl.SCANCODE_RETURN:
+		if km.action == "" || km.value == "" {
+			km.mode = "Main"
+			break
+		}
+
+		// Slots are presets named slot<n>, the same for every action.
+		name := "slot" + km.value
+
+		reply, err := km.App.Command([]string{"preset", km.action, name})
+		km.show(km.action+" "+name, reply, err)
+
+		km.action = ""
+		km.mode = "Main"
+	default:
+		km.value = km.value + codeToString(code)
+		km.App.SetValue(km.value)
+	}
+
+	return km.mode
+}
+
+func (km *keyMap2) show(label, reply string, err error) {
+	if err != nil {
+		fmt.Printf("Preset: %v\n", err)
+		km.App.SetText("Preset", "error")
+		return
+	}
+	fmt.Println(reply)
+	km.App.SetText("Preset", label)
+}
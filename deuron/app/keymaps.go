@@ -92,6 +92,9 @@ type keyMapBase struct {
 	property string
 	field    string
 	cmd      []string
+
+	// A value is being typed for property.
+	entering bool
 }
 
 func (km *keyMapBase) handle(code sdl.Scancode, mode string) string {
@@ -104,6 +107,7 @@ func (km *keyMapBase) handle(code sdl.Scancode, mode string) string {
 		case sdl.SCANCODE_RIGHTBRACKET:
 			fmt.Println("Cancelling, changing back to Main")
 			km.App.SetText(km.property+" "+km.field, "--")
+			km.entering = false
 			km.mode = "Main"
 			break
 		}
@@ -114,6 +118,14 @@ func (km *keyMapBase) handle(code sdl.Scancode, mode string) string {
 
 func (km *keyMapBase) reset() {
 	km.value = ""
+	km.entering = false
+}
+
+// enter adds a key to the value being typed.
+func (km *keyMapBase) enter(code sdl.Scancode) {
+	km.value = km.value + codeToString(code)
+	// Update gui
+	km.App.SetValue(km.value)
 }
 
 func codeToInt(code sdl.Scancode) int {
@@ -166,6 +178,8 @@ func codeToString(code sdl.Scancode) string {
 		return "9"
 	case sdl.SCANCODE_PERIOD:
 		return "."
+	case sdl.SCANCODE_MINUS:
+		return "-"
 	}
 	return ""
 }
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/property"
+)
+
+// Named presets are kept in the working directory.
+const presetsFile = "presets.json"
+
+// preset handles: preset list|save|diff|apply|delete [name]
+// A preset can be named by its position in the list.
+func (v *App) preset(source string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: preset list|save|diff|apply|delete [name]")
+	}
+
+	presets, err := property.LoadPresets(presetsFile)
+	if err != nil {
+		return "", err
+	}
+
+	if args[1] == "list" {
+		lines := []string{}
+		for i, name := range presets.Names() {
+			lines = append(lines, fmt.Sprintf("%d: %s (%d properties)", i+1, name, len(presets.Presets[name])))
+		}
+		if len(lines) == 0 {
+			return "no presets", nil
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	if len(args) < 3 {
+		return "", fmt.Errorf("usage: preset %s name", args[1])
+	}
+
+	switch args[1] {
+	case "save":
+		name := args[2]
+		if err = presets.Set(name, v.snapshot()); err != nil {
+			return "", err
+		}
+		if err = presets.Save(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Saved preset `%s` with %d properties", name, len(presets.Presets[name])), nil
+	case "diff":
+		name, preset, err := presets.Find(args[2])
+		if err != nil {
+			return "", err
+		}
+		return v.presetDiff(name, preset), nil
+	case "apply":
+		name, preset, err := presets.Find(args[2])
+		if err != nil {
+			return "", err
+		}
+		diff := v.presetDiff(name, preset)
+		if err = v.applyPreset(source, preset); err != nil {
+			return "", err
+		}
+		return "Applied " + diff, nil
+	case "delete":
+		name, _, err := presets.Find(args[2])
+		if err != nil {
+			return "", err
+		}
+		delete(presets.Presets, name)
+		if err = presets.Save(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted preset `%s`", name), nil
+	}
+
+	return "", fmt.Errorf("unknown preset command `%s`, expected list, save, diff, apply or delete", args[1])
+}
+
+// snapshot captures the App's properties and, if connected and
+// created, the sim's.
+func (v *App) snapshot() property.Preset {
+	preset := v.properties.Snapshot()
+
+	if sim := v.current(); sim != nil {
+		simPreset, err := sim.Snapshot()
+		if err != nil {
+			fmt.Printf("Preset has App properties only: %v\n", err)
+		}
+		for name, value := range simPreset {
+			preset[name] = value
+		}
+	}
+
+	return preset
+}
+
+// presetDiff describes how a preset differs from the current values.
+func (v *App) presetDiff(name string, preset property.Preset) string {
+	lines := v.properties.Diff(preset)
+
+	if sim := v.current(); sim != nil {
+		simLines, err := sim.Diff(preset)
+		if err == nil {
+			lines = append(lines, simLines...)
+		}
+	}
+
+	if len(lines) == 0 {
+		return fmt.Sprintf("preset `%s`: no differences", name)
+	}
+	return fmt.Sprintf("preset `%s`:\n%s", name, strings.Join(lines, "\n"))
+}
+
+// applyPreset sets the App's properties directly and sends the rest to
+// the sim so they are recorded in its history.
+func (v *App) applyPreset(source string, preset property.Preset) error {
+	skipped := []string{}
+
+	for _, name := range preset.Names() {
+		value := preset[name]
+
+		if v.properties.Has(name) {
+			if err := v.properties.Set(name, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if v.current() == nil {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		args := append(strings.Fields(name), strconv.FormatFloat(value, 'g', -1, 64))
+		status := v.request(simulation.NewCommand("prop", args...).From(source))
+		if status.Err != nil {
+			return status.Err
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("Preset: not connected, skipped %s\n", strings.Join(skipped, ", "))
+	}
+
+	return nil
+}
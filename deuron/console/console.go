@@ -0,0 +1,187 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Where command lines come from, passed to handlers.
+const (
+	SourceStdin = "console"
+	// TCP clients are usually scripts.
+	SourceTCP = "script"
+)
+
+// Handler processes a command's arguments, args[0] is the command name.
+// source is SourceStdin or SourceTCP.
+type Handler func(source string, args []string) (string, error)
+
+type command struct {
+	name    string
+	usage   string
+	help    string
+	handler Handler
+}
+
+// Reply is sent back for every command line. On the wire it is a
+// single line beginning with "ok" or "error", for example:
+//   ok: Connected.
+//   error: unknown command `strat`, try `help`
+type Reply struct {
+	Ok      bool
+	Message string
+}
+
+func (r Reply) String() string {
+	// Replies are line oriented so multi-line messages are folded.
+	msg := strings.Replace(strings.TrimSpace(r.Message), "\n", " | ", -1)
+	if r.Ok {
+		return "ok: " + msg
+	}
+	return "error: " + msg
+}
+
+// Console is a line oriented command server. Commands are read from
+// stdin and/or a localhost TCP port and routed to registered handlers.
+type Console struct {
+	commands []*command
+
+	// Commands can arrive from several connections at once.
+	mutex sync.Mutex
+
+	quit string
+}
+
+// NewConsole creates a Console with the built-in `help` command.
+func NewConsole() *Console {
+	c := new(Console)
+	c.quit = "quit"
+	c.Register("help", "help [command]", "this help screen.", c.help)
+	return c
+}
+
+// Register adds a command. The usage and help text are used to generate `help`.
+func (c *Console) Register(name, usage, help string, handler Handler) {
+	c.commands = append(c.commands, &command{name: name, usage: usage, help: help, handler: handler})
+}
+
+// Execute parses and runs a single command line from source.
+func (c *Console) Execute(source, line string) Reply {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return Reply{Ok: false, Message: "empty command"}
+	}
+
+	cmd := c.find(args[0])
+	if cmd == nil {
+		return Reply{Ok: false, Message: fmt.Sprintf("unknown command `%s`, try `help`", args[0])}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	msg, err := cmd.handler(source, args)
+	if err != nil {
+		return Reply{Ok: false, Message: err.Error()}
+	}
+
+	return Reply{Ok: true, Message: msg}
+}
+
+func (c *Console) find(name string) *command {
+	for _, cmd := range c.commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (c *Console) help(source string, args []string) (string, error) {
+	var s strings.Builder
+
+	if len(args) > 1 {
+		cmd := c.find(args[1])
+		if cmd == nil {
+			return "", fmt.Errorf("unknown command `%s`", args[1])
+		}
+		fmt.Fprintf(&s, "'%s' %s", cmd.usage, cmd.help)
+		return s.String(), nil
+	}
+
+	s.WriteString("------------------- Help ---------------------------------\n")
+	for _, cmd := range c.commands {
+		fmt.Fprintf(&s, "'%s' %s\n", cmd.usage, cmd.help)
+	}
+	s.WriteString("----------------------------------------------------------")
+
+	return s.String(), nil
+}
+
+// Serve reads commands from r until `quit` or EOF, writing a reply for
+// each one to w. The help screen is written in full when interactive.
+func (c *Console) Serve(r io.Reader, w io.Writer, prompt, source string) {
+	scanner := bufio.NewScanner(r)
+
+	fmt.Fprint(w, prompt)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			fmt.Fprint(w, prompt)
+			continue
+		}
+
+		reply := c.Execute(source, line)
+		if prompt != "" && reply.Ok && strings.HasPrefix(line, "help") {
+			// Interactive users get the un-folded help screen.
+			fmt.Fprintln(w, reply.Message)
+		} else {
+			fmt.Fprintln(w, reply)
+		}
+
+		if strings.Fields(line)[0] == c.quit {
+			return
+		}
+		fmt.Fprint(w, prompt)
+	}
+}
+
+// ServeStdin runs the console on stdin/stdout.
+func (c *Console) ServeStdin() {
+	fmt.Println("Enter 'help' for console commands.")
+	c.Serve(os.Stdin, os.Stdout, "]", SourceStdin)
+	fmt.Println("Console exited.")
+}
+
+// ListenTCP accepts connections on localhost only, each connection is
+// served the same as stdin but without a prompt.
+func (c *Console) ListenTCP(port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Console listening on %s\n", listener.Addr())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				fmt.Printf("Console: %v\n", err)
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				c.Serve(conn, conn, "", SourceTCP)
+			}()
+		}
+	}()
+
+	return nil
+}
@@ -1,4 +1,7 @@
-package deuron
+// Package dist draws samples from common distributions. Every sampler
+// takes the generator to draw from, typically one of an rng.Manager's
+// named streams, so results are reproducible.
+package dist
 
 import (
 	"fmt"
@@ -6,26 +9,59 @@ import (
 	"math/rand"
 )
 
-var ran = rand.New(rand.NewSource(1963))
+// Uniform draws from [min, max).
+func Uniform(ran *rand.Rand, min, max float64) float64 {
+	return min + ran.Float64()*(max-min)
+}
 
-// SimplePoisson tends to spread spikes a bit more.
-// Typical values of: 15.0, 3.0 yield ISIs 5-7 with occasional 50-100s
-func SimplePoisson(scale, div, min float64) int {
-	return int(scale*math.Pow(math.E, -ran.Float64()*scale/div) + min)
+// Normal draws from a gaussian.
+func Normal(ran *rand.Rand, mean, sd float64) float64 {
+	return mean + ran.NormFloat64()*sd
 }
 
-// func fx(y, l float64) float64 {
-// 	return 1 - math.Pow(math.E, -l*y)
-// }
+// LogNormal draws a value whose log is normal with mu and sigma.
+func LogNormal(ran *rand.Rand, mu, sigma float64) float64 {
+	return math.Exp(Normal(ran, mu, sigma))
+}
 
-// func gx(y, l, h float64) float64 {
-// 	//return l * math.Pow(math.E, -h*y)
-// 	return l * math.Exp(-h/y)
-// }
+// Exponential draws from an exponential distribution with the mean.
+func Exponential(ran *rand.Rand, mean float64) float64 {
+	return ran.ExpFloat64() * mean
+}
+
+// Gamma draws from a gamma distribution using Marsaglia and Tsang's
+// method. The mean is shape*scale.
+func Gamma(ran *rand.Rand, shape, scale float64) float64 {
+	if shape < 1 {
+		return Gamma(ran, shape+1, scale) * math.Pow(ran.Float64(), 1/shape)
+	}
+
+	d := shape - 1.0/3.0
+	c := 1.0 / math.Sqrt(9.0*d)
+	for {
+		x := ran.NormFloat64()
+		v := 1.0 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := ran.Float64()
+		if u < 1.0-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1.0-v+math.Log(v)) {
+			return d * v * scale
+		}
+	}
+}
 
-// func px(l float64) float64 {
-// 	return -math.Log(ran.Float64()) / l
-// }
+// Binomial draws the number of successes of n trials.
+func Binomial(ran *rand.Rand, n int, p float64) int {
+	k := 0
+	for i := 0; i < n; i++ {
+		if ran.Float64() < p {
+			k++
+		}
+	}
+	return k
+}
 
 // ------------------------------------------------------
 // Code from:
@@ -33,27 +69,28 @@ func SimplePoisson(scale, div, min float64) int {
 // https://www.codeproject.com/Articles/25172/Simple-Random-Number-Generation
 // https://www.johndcook.com/blog/csharp_log_factorial/
 
-func GetPoisson(lambda float64) int {
+// Poisson draws a count with the mean lambda.
+func Poisson(ran *rand.Rand, lambda float64) int {
 	if lambda < 30.0 {
-		return PoissonSmall(lambda)
+		return poissonSmall(ran, lambda)
 	}
-	return PoissonLarge(lambda)
+	return poissonLarge(ran, lambda)
 }
 
-func PoissonSmall(lambda float64) int {
+func poissonSmall(ran *rand.Rand, lambda float64) int {
 	// Algorithm due to Donald Knuth, 1969.
 	p := 1.0
 	L := math.Exp(-lambda)
 	k := 0
 	for p > L {
 		k++
-		p *= GetUniform()
+		p *= ran.Float64()
 	}
 
 	return k - 1
 }
 
-func PoissonLarge(lambda float64) int {
+func poissonLarge(ran *rand.Rand, lambda float64) int {
 	// "Rejection method PA" from "The Computer Generation of
 	// Poisson Random Variables" by A. C. Atkinson,
 	// Journal of the Royal Statistical Society Series C
@@ -67,13 +104,13 @@ func PoissonLarge(lambda float64) int {
 	k := math.Log(c) - lambda - math.Log(beta)
 
 	for {
-		u := GetUniform()
+		u := ran.Float64()
 		x := (alpha - math.Log((1.0-u)/u)) / beta
 		n := math.Floor(x + 0.5)
 		if n < 0 {
 			continue
 		}
-		v := GetUniform()
+		v := ran.Float64()
 		y := alpha - beta*x
 		temp := 1.0 + math.Exp(y)
 		lhs := y + math.Log(v/(temp*temp))
@@ -88,10 +125,6 @@ func PoissonLarge(lambda float64) int {
 	}
 }
 
-func GetUniform() float64 {
-	return ran.Float64()
-}
-
 var lf = []float64{
 	0.000000000000000,
 	0.000000000000000,
@@ -350,6 +383,7 @@ var lf = []float64{
 	1156.170837573242400,
 }
 
+// LogFactorial returns log(n!), from a table up to 254.
 func LogFactorial(n int) (float64, error) {
 	if n < 0 {
 		return 0, fmt.Errorf("Bad argument (%d)", n)
@@ -360,34 +394,3 @@ func LogFactorial(n int) (float64, error) {
 
 	return lf[n], nil
 }
-
-/*
-import (
-	"fmt"
-	"math"
-	"math/rand"
-)
-
-var ran = rand.New(rand.NewSource(1963))
-
-func main() {
-
-	l := 100.0
-	for x := 0.0; x < 100.0; x += 1 {
-		r := ran.Float64()
-		fmt.Printf("%f: %f, %f, %f, %f\n", x, fx(x, l*r), px(x, 1.0)*10, px(x, 100.0)*1000, ran.ExpFloat64()*10.0)
-	}
-}
-
-// Calc ISI
-func fx(x, l float64) float64 {
-	return 1 - math.Pow(math.E, -l*x)
-}
-
-// Poisson distribution
-func px(x, l float64) float64 {
-	return -math.Log(ran.Float64()) / l
-}
-
-
-*/
@@ -0,0 +1,97 @@
+package dist
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+func Test_SamplerMeanAndVariance(t *testing.T) {
+	cases := []struct {
+		name           string
+		sample         func(ran *rand.Rand) float64
+		mean, variance float64
+	}{
+		{"uniform", func(r *rand.Rand) float64 { return Uniform(r, 2, 6) }, 4, 16.0 / 12.0},
+		{"normal", func(r *rand.Rand) float64 { return Normal(r, 3, 2) }, 3, 4},
+		{"lognormal", func(r *rand.Rand) float64 { return LogNormal(r, 0.5, 0.4) },
+			math.Exp(0.5 + 0.08), (math.Exp(0.16) - 1) * math.Exp(1+0.16)},
+		{"exponential", func(r *rand.Rand) float64 { return Exponential(r, 5) }, 5, 25},
+		{"gamma", func(r *rand.Rand) float64 { return Gamma(r, 3, 2) }, 6, 12},
+		{"gamma shape < 1", func(r *rand.Rand) float64 { return Gamma(r, 0.5, 2) }, 1, 2},
+		{"binomial", func(r *rand.Rand) float64 { return float64(Binomial(r, 20, 0.3)) }, 6, 4.2},
+		{"poisson small", func(r *rand.Rand) float64 { return float64(Poisson(r, 4)) }, 4, 4},
+		{"poisson large", func(r *rand.Rand) float64 { return float64(Poisson(r, 50)) }, 50, 50},
+	}
+
+	const n = 200000
+	for i, c := range cases {
+		ran, _ := rng.New(int64(i + 1))
+
+		sum, sq := 0.0, 0.0
+		for j := 0; j < n; j++ {
+			v := c.sample(ran)
+			sum += v
+			sq += v * v
+		}
+		mean := sum / n
+		variance := sq/n - mean*mean
+
+		if tolerance := 4 * math.Sqrt(c.variance/n); math.Abs(mean-c.mean) > tolerance {
+			t.Errorf("%s: mean %.4f, expected %.4f±%.4f", c.name, mean, c.mean, tolerance)
+		}
+		if math.Abs(variance-c.variance) > 0.05*c.variance {
+			t.Errorf("%s: variance %.4f, expected %.4f", c.name, variance, c.variance)
+		}
+	}
+}
+
+func Test_ISIDistributionMeans(t *testing.T) {
+	cases := []IDistribution{
+		NewExponentialISI(40),
+		NewGammaISI(40, 4),
+		NewGammaISI(40, 0.5),
+		NewLogNormalISI(40, 0.5),
+		NewNormalISI(40, 5),
+		NewUniformISI(40, 20),
+		NewPoissonISI(40),
+	}
+
+	const n = 100000
+	for i, d := range cases {
+		ran, _ := rng.New(int64(i + 1))
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			sum += d.Sample(ran)
+		}
+		if mean := sum / n; math.Abs(mean-d.Mean()) > 0.02*d.Mean() {
+			t.Errorf("%T: mean %.3f, expected %.3f", d, mean, d.Mean())
+		}
+	}
+}
+
+func Test_LogFactorial(t *testing.T) {
+	// The table ends at 254, Stirling's approximation takes over.
+	for _, n := range []int{0, 1, 2, 10, 170, 253, 254, 255, 256, 1000} {
+		lf, err := LogFactorial(n)
+		if err != nil {
+			t.Fatalf("%d: %v", n, err)
+		}
+		expected, _ := math.Lgamma(float64(n) + 1)
+		if math.Abs(lf-expected) > 1e-9*math.Max(1, expected) {
+			t.Errorf("log(%d!) = %.12f, expected %.12f", n, lf, expected)
+		}
+	}
+
+	a, _ := LogFactorial(254)
+	b, _ := LogFactorial(255)
+	if d := b - a - math.Log(255); math.Abs(d) > 1e-9 {
+		t.Errorf("log(255!) - log(254!) is off log(255) by %g", d)
+	}
+
+	if _, err := LogFactorial(-1); err == nil {
+		t.Errorf("expected an error for -1")
+	}
+}
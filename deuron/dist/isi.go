@@ -0,0 +1,171 @@
+package dist
+
+import (
+	"math"
+	"math/rand"
+)
+
+// IDistribution is a distribution of intervals (ms) set by its mean.
+// Negative samples are 0.
+type IDistribution interface {
+	Sample(ran *rand.Rand) float64
+
+	Mean() float64
+	SetMean(mean float64)
+}
+
+// ExponentialISI gives the intervals of a Poisson process.
+type ExponentialISI struct {
+	mean float64
+}
+
+func NewExponentialISI(mean float64) *ExponentialISI {
+	d := new(ExponentialISI)
+	d.mean = mean
+	return d
+}
+
+func (d *ExponentialISI) Sample(ran *rand.Rand) float64 {
+	return Exponential(ran, d.mean)
+}
+
+func (d *ExponentialISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *ExponentialISI) SetMean(mean float64) {
+	d.mean = mean
+}
+
+// GammaISI intervals are more regular as the shape grows, the CV is
+// 1/sqrt(shape). A shape of 1 is exponential.
+type GammaISI struct {
+	mean  float64
+	shape float64
+}
+
+func NewGammaISI(mean, shape float64) *GammaISI {
+	d := new(GammaISI)
+	d.mean = mean
+	d.shape = shape
+	return d
+}
+
+func (d *GammaISI) Sample(ran *rand.Rand) float64 {
+	return Gamma(ran, d.shape, d.mean/d.shape)
+}
+
+func (d *GammaISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *GammaISI) SetMean(mean float64) {
+	d.mean = mean
+}
+
+func (d *GammaISI) Shape() float64 {
+	return d.shape
+}
+
+func (d *GammaISI) SetShape(shape float64) {
+	d.shape = shape
+}
+
+// LogNormalISI intervals have the mean and coefficient of variation.
+type LogNormalISI struct {
+	mean float64
+	cv   float64
+}
+
+func NewLogNormalISI(mean, cv float64) *LogNormalISI {
+	d := new(LogNormalISI)
+	d.mean = mean
+	d.cv = cv
+	return d
+}
+
+func (d *LogNormalISI) Sample(ran *rand.Rand) float64 {
+	sigma2 := math.Log(1.0 + d.cv*d.cv)
+	return LogNormal(ran, math.Log(d.mean)-sigma2/2.0, math.Sqrt(sigma2))
+}
+
+func (d *LogNormalISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *LogNormalISI) SetMean(mean float64) {
+	d.mean = mean
+}
+
+// NormalISI intervals have the mean and standard deviation.
+type NormalISI struct {
+	mean float64
+	sd   float64
+}
+
+func NewNormalISI(mean, sd float64) *NormalISI {
+	d := new(NormalISI)
+	d.mean = mean
+	d.sd = sd
+	return d
+}
+
+func (d *NormalISI) Sample(ran *rand.Rand) float64 {
+	return math.Max(0.0, Normal(ran, d.mean, d.sd))
+}
+
+func (d *NormalISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *NormalISI) SetMean(mean float64) {
+	d.mean = mean
+}
+
+// UniformISI intervals are within width/2 of the mean.
+type UniformISI struct {
+	mean  float64
+	width float64
+}
+
+func NewUniformISI(mean, width float64) *UniformISI {
+	d := new(UniformISI)
+	d.mean = mean
+	d.width = width
+	return d
+}
+
+func (d *UniformISI) Sample(ran *rand.Rand) float64 {
+	return math.Max(0.0, Uniform(ran, d.mean-d.width/2.0, d.mean+d.width/2.0))
+}
+
+func (d *UniformISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *UniformISI) SetMean(mean float64) {
+	d.mean = mean
+}
+
+// PoissonISI intervals are whole ms counts with a Poisson distribution.
+type PoissonISI struct {
+	mean float64
+}
+
+func NewPoissonISI(mean float64) *PoissonISI {
+	d := new(PoissonISI)
+	d.mean = mean
+	return d
+}
+
+func (d *PoissonISI) Sample(ran *rand.Rand) float64 {
+	return float64(Poisson(ran, d.mean))
+}
+
+func (d *PoissonISI) Mean() float64 {
+	return d.mean
+}
+
+func (d *PoissonISI) SetMean(mean float64) {
+	d.mean = mean
+}
@@ -0,0 +1,152 @@
+package rng
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// DefaultMaster is the master seed used when none is given.
+const DefaultMaster int64 = 1963
+
+// Default is for code outside of a simulation, for example graph
+// demo data. Simulations have their own Manager.
+var Default = NewManager(DefaultMaster)
+
+// Manager derives independent, named streams from a single master
+// seed. Names are hierarchical, separated by "/", for example
+// "noise/3". A stream's seed is derived from its parent's seed, so
+// overriding "noise" re-seeds every noise stream while "pattern"
+// stays fixed. Overriding "noise/3" changes only that stream.
+// A Manager isn't safe for concurrent use.
+type Manager struct {
+	master int64
+
+	// Seeds set explicitly rather than derived.
+	overrides map[string]int64
+
+	// Streams handed out so far.
+	sources map[string]*Source
+}
+
+// NewManager creates a manager for a master seed.
+func NewManager(master int64) *Manager {
+	m := new(Manager)
+	m.master = master
+	m.overrides = map[string]int64{}
+	m.sources = map[string]*Source{}
+	return m
+}
+
+// Master returns the master seed.
+func (m *Manager) Master() int64 {
+	return m.master
+}
+
+// Derive mixes a parent seed and a name into an independent seed.
+func Derive(parent int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	x := uint64(parent) ^ h.Sum64()
+
+	// splitmix64 finalizer so similar names give unrelated seeds.
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return int64(x >> 1)
+}
+
+// Seed returns the seed of a named stream, either overridden or
+// derived from its parent's seed.
+func (m *Manager) Seed(name string) int64 {
+	if seed, ok := m.overrides[name]; ok {
+		return seed
+	}
+
+	parent := m.master
+	leaf := name
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		parent = m.Seed(name[:idx])
+		leaf = name[idx+1:]
+	}
+
+	return Derive(parent, leaf)
+}
+
+// Source returns the named stream's source, creating it on first use.
+func (m *Manager) Source(name string) *Source {
+	src, ok := m.sources[name]
+	if !ok {
+		src = NewSource(m.Seed(name))
+		m.sources[name] = src
+	}
+	return src
+}
+
+// Rand returns a generator on the named stream. Generators for the
+// same name share a source.
+func (m *Manager) Rand(name string) *rand.Rand {
+	return rand.New(m.Source(name))
+}
+
+// Override sets a stream's (or group's) seed before streams are
+// created, typically from a definition.
+func (m *Manager) Override(name string, seed int64) {
+	m.overrides[name] = seed
+}
+
+// Reseed overrides the seed of a stream or group and re-seeds every
+// stream already created under it.
+func (m *Manager) Reseed(name string, seed int64) error {
+	found := false
+	for n := range m.sources {
+		if n == name || strings.HasPrefix(n, name+"/") {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no random stream `%s`, available: %v", name, m.Names())
+	}
+
+	m.overrides[name] = seed
+	for n, src := range m.sources {
+		if n == name || strings.HasPrefix(n, name+"/") {
+			src.Seed(m.Seed(n))
+		}
+	}
+
+	return nil
+}
+
+// Overrides returns the seeds that were set rather than derived.
+func (m *Manager) Overrides() map[string]int64 {
+	overrides := map[string]int64{}
+	for n, seed := range m.overrides {
+		overrides[n] = seed
+	}
+	return overrides
+}
+
+// Names returns the created streams' names, sorted.
+func (m *Manager) Names() []string {
+	names := []string{}
+	for n := range m.sources {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Seeds returns the current seed of every stream created, for
+// recording with a run's results.
+func (m *Manager) Seeds() map[string]int64 {
+	seeds := map[string]int64{}
+	for n, src := range m.sources {
+		seeds[n] = src.State().Seed
+	}
+	return seeds
+}
@@ -0,0 +1,75 @@
+package rng
+
+import (
+	"math/rand"
+)
+
+// State is everything needed to put a Source back where it was.
+type State struct {
+	Seed  int64  `json:"seed"`
+	Draws uint64 `json:"draws"`
+}
+
+// Source is a math/rand source that counts the values drawn from it.
+// The standard source's state can't be saved, but re-seeding and
+// discarding the same number of draws reproduces it exactly.
+type Source struct {
+	seed  int64
+	draws uint64
+	src   rand.Source64
+}
+
+// NewSource creates a counting source.
+func NewSource(seed int64) *Source {
+	s := new(Source)
+	s.src = rand.NewSource(seed).(rand.Source64)
+	s.seed = seed
+	return s
+}
+
+// New creates a generator on a counting source. The source is
+// returned for saving and restoring the generator's state.
+func New(seed int64) (*rand.Rand, *Source) {
+	src := NewSource(seed)
+	return rand.New(src), src
+}
+
+// Int63 implements rand.Source.
+func (s *Source) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+// Uint64 implements rand.Source64.
+func (s *Source) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+// Seed implements rand.Source and resets the draw count.
+func (s *Source) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// Reset re-seeds with the current seed.
+func (s *Source) Reset() {
+	s.Seed(s.seed)
+}
+
+// State returns the seed and the number of draws since seeding.
+func (s *Source) State() State {
+	return State{Seed: s.seed, Draws: s.draws}
+}
+
+// Restore re-seeds and replays the draws. The time taken is
+// proportional to the number of draws.
+func (s *Source) Restore(st State) {
+	s.Seed(st.Seed)
+	for i := uint64(0); i < st.Draws; i++ {
+		// Int63 and Uint64 both advance the source by one.
+		s.src.Uint64()
+	}
+	s.draws = st.Draws
+}
@@ -0,0 +1,506 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wdevore/Deuron4/simulation/pattern"
+)
+
+// Definition describes a simulation. It is typically loaded from a
+// json file located in the simulation's working directory, for example:
+//
+//	{
+//	  "duration": 1000,
+//	  "neuron": {"type": "proto", "threshold": 1.0},
+//	  "synapses": {"count": 10, "excitatoryRatio": 0.8},
+//	  "poisson": {"max": 300.0, "spread": 50.0, "min": 7.0},
+//	  "seeds": {"master": 1963, "streams": {"pattern": 123}},
+//	  "pattern": {
+//	    "poisson": {"max": 300.0, "spread": 50.0, "min": 50.0},
+//	    "streams": ["0000100001001001001000100", ...]
+//	  }
+//	}
+//
+// Instead of streams a pattern can name a file: "pattern": {"file": "a"}
+type Definition struct {
+	Name string `json:"name"`
+
+	// How long (ms) to run before resetting. Continuous simulations
+	// never reset and use it as the length of the sample window.
+	Duration int `json:"duration"`
+
+	Neuron   NeuronDef   `json:"neuron"`
+	Synapses SynapsesDef `json:"synapses"`
+
+	// Poisson noise applied to every synapse.
+	Poisson PoissonDef `json:"poisson"`
+	// Noise with a rate (Hz) that changes with time. It replaces
+	// Poisson.
+	Rate *RateDef `json:"rate,omitempty"`
+	// Periodic or oscillatory firing. It replaces Poisson and Rate.
+	Stream *StreamDef `json:"stream,omitempty"`
+
+	Seeds   SeedsDef   `json:"seeds"`
+	Pattern PatternDef `json:"pattern"`
+
+	// Values by property name, e.g. {"Poisson Max": 250}, applied once
+	// the network is created. See the `props` command for the names.
+	Properties map[string]float64 `json:"properties,omitempty"`
+}
+
+// NeuronDef describes the neuron under test.
+type NeuronDef struct {
+	// "proto" is the only type available at the moment.
+	Type string `json:"type"`
+
+	Threshold float64 `json:"threshold"`
+	APDecay   float64 `json:"apDecay"`
+	MaxAP     float64 `json:"maxAP"`
+
+	// STDP time-constants
+	TaoP float64 `json:"taoP"`
+	TaoN float64 `json:"taoN"`
+	TaoY float64 `json:"taoY"`
+}
+
+// SynapsesDef describes how many synapses are created and how they
+// are split between excititory and inhibitory.
+type SynapsesDef struct {
+	Count int `json:"count"`
+
+	// The remaining synapses are inhibitory.
+	ExcitatoryRatio float64 `json:"excitatoryRatio"`
+
+	// Named groups of synapse IDs, e.g. {"distal": "0-3,8"}, for
+	// addressing their noise streams: prop Poisson Max@distal 100
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// How the pattern's streams reach the synapses, shared (the
+	// default) or each.
+	Routing string `json:"routing,omitempty"`
+}
+
+// Pattern routings
+const (
+	// Every synapse receives stream 0.
+	RoutingShared = "shared"
+	// Stream n goes to synapse n, synapses beyond the pattern receive
+	// none.
+	RoutingEach = "each"
+)
+
+// PoissonDef holds the properties used for generating ISIs.
+type PoissonDef struct {
+	Max    float64 `json:"max"`
+	Spread float64 `json:"spread"`
+	Min    float64 `json:"min"`
+
+	// Draws the ISIs, plus Min, from a distribution. Max and Spread
+	// are then unused.
+	ISI *ISIDef `json:"isi,omitempty"`
+}
+
+// ISI distributions
+const (
+	ISIExponential = "exponential"
+	ISIGamma       = "gamma"
+	ISILogNormal   = "lognormal"
+	ISINormal      = "normal"
+	ISIUniform     = "uniform"
+	ISIPoisson     = "poisson"
+)
+
+// ISIDef describes an ISI distribution by its mean (ms) and, depending
+// on the type, how regular it is, for example:
+//
+//	{"type": "gamma", "mean": 40, "shape": 4}
+//	{"type": "lognormal", "mean": 40, "cv": 0.5}
+type ISIDef struct {
+	// exponential, gamma, lognormal, normal, uniform or poisson.
+	Type string  `json:"type"`
+	Mean float64 `json:"mean"`
+
+	// gamma: larger shapes are more regular, 1 is exponential.
+	Shape float64 `json:"shape,omitempty"`
+	// lognormal: the coefficient of variation.
+	CV float64 `json:"cv,omitempty"`
+	// normal: the standard deviation (ms).
+	SD float64 `json:"sd,omitempty"`
+	// uniform: the width (ms) of the range around the mean.
+	Width float64 `json:"width,omitempty"`
+}
+
+// Rate types
+const (
+	RateConstant = "constant"
+	RateSine     = "sine"
+	RateStep     = "step"
+	RateRamp     = "ramp"
+	RateSamples  = "samples"
+)
+
+// RateDef describes noise generated as an inhomogeneous Poisson
+// process whose rate (Hz) is a function of time (ms), for example:
+//
+//	{"type": "constant", "rate": 10, "refractory": 2}
+//	{"type": "sine", "rate": 20, "amplitude": 15, "frequency": 8}
+//	{"type": "step", "rate": 5, "to": 40, "start": 500}
+//	{"type": "ramp", "rate": 5, "to": 40, "start": 200, "end": 800}
+//	{"type": "samples", "file": "rate.csv"}
+type RateDef struct {
+	// constant (the default), sine, step, ramp or samples.
+	Type string `json:"type"`
+
+	// The rate, the mean rate of sine or the rate before a step or
+	// ramp.
+	Rate float64 `json:"rate"`
+
+	// step and ramp: the rate after Start (step) or End (ramp).
+	To    float64 `json:"to,omitempty"`
+	Start float64 `json:"start,omitempty"`
+	End   float64 `json:"end,omitempty"`
+
+	// sine: the modulation's amplitude (Hz), frequency (Hz) and phase
+	// (degrees).
+	Amplitude float64 `json:"amplitude,omitempty"`
+	Frequency float64 `json:"frequency,omitempty"`
+	Phase     float64 `json:"phase,omitempty"`
+
+	// samples: pairs of time (ms) and rate (Hz), interpolated
+	// linearly, or a csv file of them relative to the definition.
+	Samples [][]float64 `json:"samples,omitempty"`
+	File    string      `json:"file,omitempty"`
+
+	// No spikes for this long (ms) after a spike.
+	Refractory float64 `json:"refractory,omitempty"`
+}
+
+// Stream types
+const (
+	StreamRegular     = "regular"
+	StreamBurst       = "burst"
+	StreamOscillation = "oscillation"
+)
+
+// StreamDef describes firing that drives every synapse instead of
+// noise, for example:
+//
+//	{"type": "regular", "period": 100, "stagger": 7}
+//	{"type": "burst", "period": 125, "spikes": 4, "interval": 5}
+//	{"type": "oscillation", "rate": 10, "theta": 8, "thetaPhase": 180,
+//	 "thetaLocking": 2, "gamma": 40, "gammaLocking": 1}
+type StreamDef struct {
+	// regular, burst or oscillation.
+	Type string `json:"type"`
+
+	// regular and burst: spikes (or bursts) every period (ms) from the
+	// offset (ms). Stream n starts n*stagger (ms) later.
+	Period  int `json:"period,omitempty"`
+	Offset  int `json:"offset,omitempty"`
+	Stagger int `json:"stagger,omitempty"`
+
+	// burst: the spikes per burst and the interval (ms) between them.
+	Spikes   int `json:"spikes,omitempty"`
+	Interval int `json:"interval,omitempty"`
+
+	// oscillation: the mean rate (Hz), locked to theta and gamma
+	// oscillations (Hz). Phases are the preferred phases (degrees) and
+	// lockings von Mises concentrations, 0 for none.
+	Rate         float64 `json:"rate,omitempty"`
+	Theta        float64 `json:"theta,omitempty"`
+	ThetaPhase   float64 `json:"thetaPhase,omitempty"`
+	ThetaLocking float64 `json:"thetaLocking,omitempty"`
+	Gamma        float64 `json:"gamma,omitempty"`
+	GammaPhase   float64 `json:"gammaPhase,omitempty"`
+	GammaLocking float64 `json:"gammaLocking,omitempty"`
+
+	// oscillation: no spikes for this long (ms) after a spike.
+	Refractory float64 `json:"refractory,omitempty"`
+}
+
+// SeedsDef holds the random seeds. Every random stream's seed is
+// derived from the master seed unless it is overridden by name, for
+// example "pattern", "noise" (all noise streams) or "noise/3".
+type SeedsDef struct {
+	Master  int64            `json:"master"`
+	Streams map[string]int64 `json:"streams,omitempty"`
+}
+
+// PatternDef describes the stimulus pattern and how often it is presented.
+type PatternDef struct {
+	// When the pattern is presented.
+	Schedule ScheduleDef `json:"schedule"`
+
+	// The ISI between pattern presentations for the poisson schedule.
+	Poisson PoissonDef `json:"poisson"`
+
+	// One bit string per stream, for example "0010010". The bits are
+	// written in the same order as SpikeStream.SetSpikes expects them.
+	Streams []string `json:"streams"`
+
+	// A pattern from the library (the patterns directory next to the
+	// definition) by name, or a pattern file. It replaces Streams.
+	File string `json:"file,omitempty"`
+
+	// Changes the pattern every time it is presented.
+	Perturb PerturbDef `json:"perturb"`
+
+	// Labeled patterns presented in turn on the same streams. It
+	// replaces Streams and File.
+	Set *SetDef `json:"set,omitempty"`
+}
+
+// Width returns the length (ms) of the pattern, or of a set's longest
+// pattern, 0 without one.
+func (p *PatternDef) Width() int {
+	if p.Set == nil {
+		if len(p.Streams) == 0 {
+			return 0
+		}
+		return len(p.Streams[0])
+	}
+
+	width := 0
+	for _, lp := range p.Set.Patterns {
+		if len(lp.Streams) > 0 && len(lp.Streams[0]) > width {
+			width = len(lp.Streams[0])
+		}
+	}
+	return width
+}
+
+// Pattern set orders
+const (
+	OrderRandom     = "random"
+	OrderRoundRobin = "roundRobin"
+	OrderScripted   = "scripted"
+)
+
+// SetDef describes labeled patterns of which one is chosen for each
+// presentation, for example:
+//
+//	{"order": "scripted", "script": ["A", "A", "B"],
+//	 "patterns": [{"label": "A", "file": "a-1"}, {"label": "B", "file": "a-2"}]}
+type SetDef struct {
+	// random (the default), roundRobin or scripted.
+	Order string `json:"order"`
+	// scripted: the labels in presentation order, repeated.
+	Script []string `json:"script,omitempty"`
+
+	Patterns []LabeledPatternDef `json:"patterns"`
+}
+
+// LabeledPatternDef is a pattern of a set, given by its streams or a
+// pattern file.
+type LabeledPatternDef struct {
+	Label   string   `json:"label"`
+	Streams []string `json:"streams,omitempty"`
+	File    string   `json:"file,omitempty"`
+}
+
+// Schedule types
+const (
+	SchedulePoisson = "poisson"
+	ScheduleFixed   = "fixed"
+	ScheduleList    = "list"
+	ScheduleGamma   = "gamma"
+)
+
+// ScheduleDef describes when a pattern is presented, for example:
+//
+//	{"type": "fixed", "period": 100}
+//	{"type": "list", "times": [100, 250, 900]}
+//	{"type": "gamma", "mean": 200, "shape": 4, "min": 20}
+//
+// The poisson schedule waits an ISI drawn using pattern.poisson after
+// each presentation.
+type ScheduleDef struct {
+	// poisson (the default), fixed, list or gamma.
+	Type string `json:"type"`
+
+	// fixed: the time (ms) between presentation onsets.
+	Period int `json:"period,omitempty"`
+
+	// list: the presentation onsets (ms) after each reset.
+	Times []int `json:"times,omitempty"`
+
+	// gamma: the ISI after each presentation is min plus a gamma
+	// distributed time with the mean (ms) and shape.
+	Mean  float64 `json:"mean,omitempty"`
+	Shape float64 `json:"shape,omitempty"`
+	Min   float64 `json:"min,omitempty"`
+}
+
+// PerturbDef describes the random changes made to each pattern
+// presentation. Spikes are deleted, jittered and then inserted.
+type PerturbDef struct {
+	// Standard deviation (ms) of each spike's gaussian jitter.
+	Jitter float64 `json:"jitter"`
+	// Probability of deleting each spike.
+	Deletion float64 `json:"deletion"`
+	// Rate (Hz) of extra spikes.
+	Insertion float64 `json:"insertion"`
+}
+
+// Default returns the definition the simulations have always run with.
+func Default() *Definition {
+	d := new(Definition)
+	d.Name = "default"
+	d.Duration = 1000 // 1000ms
+
+	d.Neuron.Type = "proto"
+
+	d.Synapses.Count = 10
+	d.Synapses.ExcitatoryRatio = 0.8
+
+	d.Poisson = PoissonDef{Max: 300.0, Spread: 50.0, Min: 7.0}
+
+	d.Seeds.Master = 1963
+
+	d.Pattern.Schedule.Type = SchedulePoisson
+	d.Pattern.Poisson = PoissonDef{Max: 300.0, Spread: 50.0, Min: 50.0}
+	d.Pattern.Streams = []string{
+		"0000100001001001001000100",
+		"0001001000001000100001000",
+		"1001000010010010000100001",
+		"0100000000010000001001000",
+		"0000100001000100000000010",
+		"0000100010000000000010000",
+		"1000000100000010000000100",
+		"0000010001000000010000001",
+		"0010000101000100000000001",
+		"0000000000010100001001001",
+	}
+
+	return d
+}
+
+// Load reads and validates a definition file. Values missing from the
+// file keep their Default() value.
+func Load(path string) (*Definition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data, filepath.Dir(path))
+}
+
+// Parse decodes and validates a json definition. Pattern files are
+// relative to the working directory.
+func Parse(data []byte) (*Definition, error) {
+	return parse(data, ".")
+}
+
+func parse(data []byte, dir string) (*Definition, error) {
+	// First pass: catch misspelled or unknown fields.
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, syntaxError(data, err)
+	}
+
+	if err := checkFields(raw, definitionType, "$"); err != nil {
+		return nil, err
+	}
+
+	d := Default()
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(d); err != nil {
+		if te, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, &PathError{Path: "$." + te.Field, Msg: fmt.Sprintf("expected %s but found %s", te.Type, te.Value)}
+		}
+		return nil, err
+	}
+
+	if d.Pattern.File != "" {
+		p, err := pattern.NewLibrary(dir).Find(d.Pattern.File)
+		if err != nil {
+			return nil, &PathError{Path: "$.pattern.file", Msg: err.Error()}
+		}
+		d.Pattern.Streams = p.Definition()
+	}
+
+	if d.Pattern.Set != nil {
+		for i := range d.Pattern.Set.Patterns {
+			lp := &d.Pattern.Set.Patterns[i]
+			if lp.File == "" {
+				continue
+			}
+			p, err := pattern.NewLibrary(dir).Find(lp.File)
+			if err != nil {
+				return nil, &PathError{Path: fmt.Sprintf("$.pattern.set.patterns[%d].file", i), Msg: err.Error()}
+			}
+			lp.Streams = p.Definition()
+		}
+	}
+
+	if d.Rate != nil && d.Rate.File != "" {
+		path := d.Rate.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		samples, err := readRateSamples(path)
+		if err != nil {
+			return nil, &PathError{Path: "$.rate.file", Msg: err.Error()}
+		}
+		d.Rate.Samples = samples
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// readRateSamples reads a csv file of time (ms) and rate (Hz) rows.
+// Lines starting with # and a header row are skipped.
+func readRateSamples(path string) ([][]float64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := [][]float64{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected time,rate", i+1)
+		}
+		t, errT := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		rate, errR := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if errT != nil || errR != nil {
+			if len(samples) == 0 {
+				// A header
+				continue
+			}
+			return nil, fmt.Errorf("line %d: expected numbers, got `%s`", i+1, line)
+		}
+		samples = append(samples, []float64{t, rate})
+	}
+
+	return samples, nil
+}
+
+// Bits converts a bit string into the byte form used by SpikeStream.
+func Bits(s string) []byte {
+	bits := make([]byte, len(s))
+	for i, c := range s {
+		if c == '1' {
+			bits[i] = 1
+		}
+	}
+	return bits
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// paths returns the paths of a Parse error, nil for none.
+func paths(t *testing.T, err error) []string {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *PathError:
+		return []string{e.Path}
+	case Errors:
+		ps := make([]string, len(e))
+		for i, pe := range e {
+			ps[i] = pe.Path
+		}
+		return ps
+	default:
+		t.Fatalf("expected a PathError, got %T: %v", err, err)
+		return nil
+	}
+}
+
+func Test_ParseErrorPaths(t *testing.T) {
+	cases := []struct {
+		name  string
+		json  string
+		paths []string
+	}{
+		{"defaults", `{}`, nil},
+		{"syntax", `{"duration": }`, []string{"$"}},
+		{"unknown field", `{"neuron": {"threshhold": 1}}`, []string{"$.neuron.threshhold"}},
+		{"type", `{"duration": "long"}`, []string{"$.duration"}},
+		{"duration", `{"duration": 0}`, []string{"$.duration"}},
+		{"neuron type", `{"neuron": {"type": "lif"}}`, []string{"$.neuron.type"}},
+		{"synapses", `{"synapses": {"count": 10, "excitatoryRatio": 1.5}}`, []string{"$.synapses.excitatoryRatio"}},
+		{"tag name", `{"synapses": {"count": 10, "tags": {"exc": "0-3"}}}`, []string{"$.synapses.tags.exc"}},
+		{"tag ids", `{"synapses": {"count": 10, "tags": {"distal": "8-12"}}}`, []string{"$.synapses.tags.distal"}},
+		{"routing", `{"synapses": {"count": 10, "routing": "one"}}`, []string{"$.synapses.routing"}},
+		{"poisson", `{"poisson": {"max": 0, "spread": 50, "min": -1}}`, []string{"$.poisson.max", "$.poisson.min"}},
+		{"isi", `{"poisson": {"isi": {"type": "gamma", "mean": 40}}}`, []string{"$.poisson.isi.shape"}},
+		{"isi with rate", `{"poisson": {"isi": {"type": "exponential", "mean": 40}}, "rate": {"rate": 10}}`,
+			[]string{"$.poisson.isi"}},
+		{"rate", `{"rate": {"type": "ramp", "rate": 5, "to": 40, "start": 800, "end": 200}}`, []string{"$.rate.end"}},
+		{"rate type", `{"rate": {"type": "square"}}`, []string{"$.rate.type"}},
+		{"stream", `{"stream": {"type": "regular"}}`, []string{"$.stream.period"}},
+		{"burst", `{"stream": {"type": "burst", "period": 10, "spikes": 4, "interval": 5}}`, []string{"$.stream.period"}},
+		{"stream with rate", `{"rate": {"rate": 10}, "stream": {"type": "regular", "period": 100}}`, []string{"$.stream"}},
+		{"schedule", `{"pattern": {"schedule": {"type": "list"}}}`, []string{"$.pattern.schedule.times"}},
+		{"schedule isi", `{"pattern": {"schedule": {"type": "fixed", "period": 100}, "poisson": {"isi": {"type": "exponential", "mean": 40}}}}`,
+			[]string{"$.pattern.poisson.isi"}},
+		{"perturb", `{"pattern": {"perturb": {"deletion": 2}}}`, []string{"$.pattern.perturb.deletion"}},
+		{"streams", `{"synapses": {"count": 3}, "pattern": {"streams": ["0101", "01", "01x1"]}}`,
+			[]string{"$.pattern.streams[1]", "$.pattern.streams[2]"}},
+		{"too many streams", `{"synapses": {"count": 1}, "pattern": {"streams": ["01", "10"]}}`, []string{"$.pattern.streams"}},
+		{"set", `{"pattern": {"set": {"order": "scripted", "script": ["B"], "patterns": [{"label": "A", "streams": ["01"]}]}}}`,
+			[]string{"$.pattern.set.script[0]"}},
+	}
+
+	for _, c := range cases {
+		_, err := Parse([]byte(c.json))
+		if got := paths(t, err); !reflect.DeepEqual(got, c.paths) {
+			t.Errorf("%s: paths %v, expected %v (%v)", c.name, got, c.paths, err)
+		}
+	}
+}
+
+func Test_PatternWidth(t *testing.T) {
+	cases := []struct {
+		name  string
+		json  string
+		width int
+	}{
+		{"default", `{}`, 25},
+		{"streams", `{"pattern": {"streams": ["0101", "0011"]}}`, 4},
+		{"set", `{"pattern": {"set": {"patterns": [{"label": "A", "streams": ["01"]}, {"label": "B", "streams": ["00011"]}]}}}`, 5},
+	}
+
+	for _, c := range cases {
+		d, err := Parse([]byte(c.json))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if w := d.Pattern.Width(); w != c.width {
+			t.Errorf("%s: width %d, expected %d", c.name, w, c.width)
+		}
+	}
+}
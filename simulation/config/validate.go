@@ -0,0 +1,463 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/wdevore/Deuron4/simulation/property"
+)
+
+// PathError reports a problem with a value in a definition.
+// Path is a json path, for example: $.pattern.streams[3]
+type PathError struct {
+	Path string
+	Msg  string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// Errors collects every problem found during validation.
+type Errors []*PathError
+
+func (e Errors) Error() string {
+	var s strings.Builder
+	for i, pe := range e {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(pe.Error())
+	}
+	return s.String()
+}
+
+var definitionType = reflect.TypeOf(Definition{})
+
+// Validate checks the definition's values.
+func (d *Definition) Validate() error {
+	var errs Errors
+
+	add := func(path, format string, a ...interface{}) {
+		errs = append(errs, &PathError{Path: path, Msg: fmt.Sprintf(format, a...)})
+	}
+
+	if d.Duration <= 0 {
+		add("$.duration", "must be > 0, got %d", d.Duration)
+	}
+
+	switch d.Neuron.Type {
+	case "proto":
+	default:
+		add("$.neuron.type", "unknown neuron type `%s`, expected `proto`", d.Neuron.Type)
+	}
+
+	if d.Synapses.Count <= 0 {
+		add("$.synapses.count", "must be > 0, got %d", d.Synapses.Count)
+	}
+
+	if d.Synapses.ExcitatoryRatio < 0.0 || d.Synapses.ExcitatoryRatio > 1.0 {
+		add("$.synapses.excitatoryRatio", "must be within [0, 1], got %f", d.Synapses.ExcitatoryRatio)
+	}
+
+	switch d.Synapses.Routing {
+	case "", RoutingShared, RoutingEach:
+	default:
+		add("$.synapses.routing", "unknown routing `%s`, expected shared or each", d.Synapses.Routing)
+	}
+
+	for tag, ids := range d.Synapses.Tags {
+		path := "$.synapses.tags." + tag
+		if !validTag(tag) {
+			add(path, "invalid tag, expected a name other than exc, inh or all, e.g. `distal`")
+		}
+		if _, err := property.ParseIDs(ids, d.Synapses.Count); err != nil {
+			add(path, "%v", err)
+		}
+	}
+
+	for name := range d.Seeds.Streams {
+		if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.Contains(name, "//") {
+			add("$.seeds.streams", "invalid stream name `%s`, expected for example `noise` or `noise/3`", name)
+		}
+	}
+
+	validatePoisson(d.Poisson, "$.poisson", add)
+	validatePoisson(d.Pattern.Poisson, "$.pattern.poisson", add)
+	if d.Rate != nil {
+		validateRate(d.Rate, "$.rate", add)
+	}
+	if d.Stream != nil {
+		if d.Rate != nil {
+			add("$.stream", "can't be used with a rate")
+		}
+		validateStream(d.Stream, "$.stream", add)
+	}
+
+	// Only Poisson noise and the poisson schedule draw ISIs.
+	if d.Poisson.ISI != nil && (d.Rate != nil || d.Stream != nil) {
+		add("$.poisson.isi", "is only used by Poisson noise, not with a rate or stream")
+	}
+	if d.Pattern.Poisson.ISI != nil && d.Pattern.Schedule.Type != SchedulePoisson {
+		add("$.pattern.poisson.isi", "is only used by the poisson schedule, not `%s`", d.Pattern.Schedule.Type)
+	}
+
+	validateSchedule(d.Pattern.Schedule, "$.pattern.schedule", add)
+
+	if d.Pattern.Perturb.Jitter < 0.0 {
+		add("$.pattern.perturb.jitter", "must be >= 0, got %f", d.Pattern.Perturb.Jitter)
+	}
+	if d.Pattern.Perturb.Deletion < 0.0 || d.Pattern.Perturb.Deletion > 1.0 {
+		add("$.pattern.perturb.deletion", "must be within [0, 1], got %f", d.Pattern.Perturb.Deletion)
+	}
+	if d.Pattern.Perturb.Insertion < 0.0 || d.Pattern.Perturb.Insertion > 1000.0 {
+		add("$.pattern.perturb.insertion", "must be within [0, 1000] Hz, got %f", d.Pattern.Perturb.Insertion)
+	}
+
+	streamsPath := "$.pattern.streams"
+	if d.Pattern.File != "" {
+		streamsPath = "$.pattern.file"
+	}
+	if len(d.Pattern.Streams) > d.Synapses.Count {
+		add(streamsPath, "has %d streams but there are only %d synapses", len(d.Pattern.Streams), d.Synapses.Count)
+	}
+
+	for i, stream := range d.Pattern.Streams {
+		path := fmt.Sprintf("%s[%d]", streamsPath, i)
+		if len(stream) == 0 {
+			add(path, "is empty")
+			continue
+		}
+		if i > 0 && len(stream) != len(d.Pattern.Streams[0]) {
+			add(path, "length %d doesn't match stream 0 length %d", len(stream), len(d.Pattern.Streams[0]))
+		}
+		if idx := strings.IndexFunc(stream, func(c rune) bool { return c != '0' && c != '1' }); idx >= 0 {
+			add(path, "invalid character `%c` at position %d, only 0 or 1 allowed", stream[idx], idx)
+		}
+	}
+
+	if d.Pattern.Set != nil {
+		validateSet(d.Pattern.Set, d.Synapses.Count, "$.pattern.set", add)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateSet(set *SetDef, synapses int, path string, add func(path, format string, a ...interface{})) {
+	switch set.Order {
+	case "", OrderRandom, OrderRoundRobin:
+	case OrderScripted:
+		if len(set.Script) == 0 {
+			add(path+".script", "expected the labels to present in order")
+		}
+	default:
+		add(path+".order", "unknown order `%s`, expected random, roundRobin or scripted", set.Order)
+	}
+
+	if len(set.Patterns) == 0 {
+		add(path+".patterns", "expected at least one pattern")
+		return
+	}
+
+	labels := map[string]bool{}
+	for i, lp := range set.Patterns {
+		pp := fmt.Sprintf("%s.patterns[%d]", path, i)
+		if lp.Label == "" {
+			add(pp+".label", "is empty")
+		}
+		if labels[lp.Label] {
+			add(pp+".label", "`%s` is used twice", lp.Label)
+		}
+		labels[lp.Label] = true
+
+		if len(lp.Streams) == 0 {
+			add(pp, "expected streams or a file")
+			continue
+		}
+		if len(lp.Streams) != len(set.Patterns[0].Streams) {
+			add(pp, "has %d streams but pattern 0 has %d", len(lp.Streams), len(set.Patterns[0].Streams))
+		}
+		if len(lp.Streams) > synapses {
+			add(pp, "has %d streams but there are only %d synapses", len(lp.Streams), synapses)
+		}
+		for j, stream := range lp.Streams {
+			if len(stream) == 0 {
+				add(fmt.Sprintf("%s.streams[%d]", pp, j), "is empty")
+				continue
+			}
+			if len(stream) != len(lp.Streams[0]) {
+				add(fmt.Sprintf("%s.streams[%d]", pp, j), "length %d doesn't match stream 0 length %d", len(stream), len(lp.Streams[0]))
+			}
+			if idx := strings.IndexFunc(stream, func(c rune) bool { return c != '0' && c != '1' }); idx >= 0 {
+				add(fmt.Sprintf("%s.streams[%d]", pp, j), "invalid character `%c` at position %d, only 0 or 1 allowed", stream[idx], idx)
+			}
+		}
+	}
+
+	for i, label := range set.Script {
+		if !labels[label] {
+			add(fmt.Sprintf("%s.script[%d]", path, i), "no pattern labeled `%s`", label)
+		}
+	}
+}
+
+// validTag reports if tag is a name that can't be mistaken for another
+// selector: a letter followed by letters, digits or underscores.
+func validTag(tag string) bool {
+	if tag == "" || tag == "exc" || tag == "inh" || tag == "all" {
+		return false
+	}
+	for i, c := range tag {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func validatePoisson(p PoissonDef, path string, add func(path, format string, a ...interface{})) {
+	if p.Max <= 0.0 {
+		add(path+".max", "must be > 0, got %f", p.Max)
+	}
+	if p.Spread <= 0.0 {
+		add(path+".spread", "must be > 0, got %f", p.Spread)
+	}
+	if p.Min < 0.0 {
+		add(path+".min", "must be >= 0, got %f", p.Min)
+	}
+	if p.ISI != nil {
+		validateISI(p.ISI, path+".isi", add)
+	}
+}
+
+func validateISI(d *ISIDef, path string, add func(path, format string, a ...interface{})) {
+	if d.Mean <= 0.0 {
+		add(path+".mean", "must be > 0, got %f", d.Mean)
+	}
+
+	switch d.Type {
+	case ISIExponential, ISIPoisson:
+	case ISIGamma:
+		if d.Shape <= 0.0 {
+			add(path+".shape", "must be > 0, got %f", d.Shape)
+		}
+	case ISILogNormal:
+		if d.CV <= 0.0 {
+			add(path+".cv", "must be > 0, got %f", d.CV)
+		}
+	case ISINormal:
+		if d.SD < 0.0 {
+			add(path+".sd", "must be >= 0, got %f", d.SD)
+		}
+	case ISIUniform:
+		if d.Width < 0.0 {
+			add(path+".width", "must be >= 0, got %f", d.Width)
+		}
+	default:
+		add(path+".type", "unknown distribution `%s`, expected exponential, gamma, lognormal, normal, uniform or poisson", d.Type)
+	}
+}
+
+func validateRate(r *RateDef, path string, add func(path, format string, a ...interface{})) {
+	if r.Rate < 0.0 {
+		add(path+".rate", "must be >= 0, got %f", r.Rate)
+	}
+	if r.Refractory < 0.0 {
+		add(path+".refractory", "must be >= 0, got %f", r.Refractory)
+	}
+
+	switch r.Type {
+	case "", RateConstant:
+	case RateSine:
+		if r.Frequency <= 0.0 {
+			add(path+".frequency", "must be > 0, got %f", r.Frequency)
+		}
+		if r.Rate+math.Abs(r.Amplitude) <= 0.0 {
+			add(path+".amplitude", "the rate never exceeds 0")
+		}
+	case RateStep:
+		if r.To < 0.0 {
+			add(path+".to", "must be >= 0, got %f", r.To)
+		}
+	case RateRamp:
+		if r.To < 0.0 {
+			add(path+".to", "must be >= 0, got %f", r.To)
+		}
+		if r.End <= r.Start {
+			add(path+".end", "must be > start (%f), got %f", r.Start, r.End)
+		}
+	case RateSamples:
+		samplesPath := path + ".samples"
+		if r.File != "" {
+			samplesPath = path + ".file"
+		}
+		if len(r.Samples) == 0 {
+			add(samplesPath, "expected at least one time and rate")
+		}
+		for i, sample := range r.Samples {
+			sp := fmt.Sprintf("%s[%d]", samplesPath, i)
+			if len(sample) != 2 {
+				add(sp, "expected a time (ms) and rate (Hz), got %d values", len(sample))
+				continue
+			}
+			if sample[1] < 0.0 {
+				add(sp, "rate must be >= 0, got %f", sample[1])
+			}
+			if i > 0 && len(r.Samples[i-1]) == 2 && sample[0] <= r.Samples[i-1][0] {
+				add(sp, "time %f must be after the previous sample's %f", sample[0], r.Samples[i-1][0])
+			}
+		}
+	default:
+		add(path+".type", "unknown rate `%s`, expected constant, sine, step, ramp or samples", r.Type)
+	}
+}
+
+func validateStream(st *StreamDef, path string, add func(path, format string, a ...interface{})) {
+	switch st.Type {
+	case StreamRegular, StreamBurst:
+		if st.Period <= 0 {
+			add(path+".period", "must be > 0, got %d", st.Period)
+		}
+		if st.Offset < 0 {
+			add(path+".offset", "must be >= 0, got %d", st.Offset)
+		}
+		if st.Stagger < 0 {
+			add(path+".stagger", "must be >= 0, got %d", st.Stagger)
+		}
+		if st.Type == StreamRegular {
+			break
+		}
+		if st.Spikes <= 0 {
+			add(path+".spikes", "must be > 0, got %d", st.Spikes)
+		}
+		if st.Interval <= 0 {
+			add(path+".interval", "must be > 0, got %d", st.Interval)
+		}
+		if st.Spikes > 0 && st.Interval > 0 && (st.Spikes-1)*st.Interval >= st.Period {
+			add(path+".period", "a burst of %d spikes %dms apart doesn't fit into %dms", st.Spikes, st.Interval, st.Period)
+		}
+	case StreamOscillation:
+		if st.Rate < 0.0 {
+			add(path+".rate", "must be >= 0, got %f", st.Rate)
+		}
+		if st.Theta < 0.0 {
+			add(path+".theta", "must be >= 0, got %f", st.Theta)
+		}
+		if st.Gamma < 0.0 {
+			add(path+".gamma", "must be >= 0, got %f", st.Gamma)
+		}
+		if st.ThetaLocking < 0.0 || st.ThetaLocking > 50.0 {
+			add(path+".thetaLocking", "must be within [0, 50], got %f", st.ThetaLocking)
+		}
+		if st.GammaLocking < 0.0 || st.GammaLocking > 50.0 {
+			add(path+".gammaLocking", "must be within [0, 50], got %f", st.GammaLocking)
+		}
+		if st.Refractory < 0.0 {
+			add(path+".refractory", "must be >= 0, got %f", st.Refractory)
+		}
+	default:
+		add(path+".type", "unknown stream `%s`, expected regular, burst or oscillation", st.Type)
+	}
+}
+
+func validateSchedule(s ScheduleDef, path string, add func(path, format string, a ...interface{})) {
+	switch s.Type {
+	case SchedulePoisson:
+	case ScheduleFixed:
+		if s.Period <= 0 {
+			add(path+".period", "must be > 0, got %d", s.Period)
+		}
+	case ScheduleList:
+		if len(s.Times) == 0 {
+			add(path+".times", "expected at least one presentation time")
+		}
+		for i, t := range s.Times {
+			if t < 0 {
+				add(fmt.Sprintf("%s.times[%d]", path, i), "must be >= 0, got %d", t)
+			}
+		}
+	case ScheduleGamma:
+		if s.Mean <= 0.0 {
+			add(path+".mean", "must be > 0, got %f", s.Mean)
+		}
+		if s.Shape <= 0.0 {
+			add(path+".shape", "must be > 0, got %f", s.Shape)
+		}
+		if s.Min < 0.0 {
+			add(path+".min", "must be >= 0, got %f", s.Min)
+		}
+	default:
+		add(path+".type", "unknown schedule `%s`, expected poisson, fixed, list or gamma", s.Type)
+	}
+}
+
+// checkFields walks the raw json comparing each object key against the
+// json tags of the target type. encoding/json's DisallowUnknownFields
+// doesn't report where the field was found, hence the walk.
+func checkFields(raw interface{}, t reflect.Type, path string) error {
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			// Type mismatches are reported by the decoder.
+			return nil
+		}
+
+		for key, value := range obj {
+			field, found := fieldByTag(t, key)
+			if !found {
+				return &PathError{Path: path + "." + key, Msg: "unknown field"}
+			}
+			if err := checkFields(value, field.Type, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		return checkFields(raw, t.Elem(), path)
+	case reflect.Slice:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+
+		for i, value := range arr {
+			if err := checkFields(value, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func fieldByTag(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// syntaxError adds the line number to json syntax errors.
+func syntaxError(data []byte, err error) error {
+	if se, ok := err.(*json.SyntaxError); ok {
+		line := bytes.Count(data[:se.Offset], []byte("\n")) + 1
+		return &PathError{Path: "$", Msg: fmt.Sprintf("line %d: %s", line, se.Error())}
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package continuous
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/network"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+/*
+This simulation simulates a single neuron that is never reset.
+Stimulus is applied for as long as the simulation runs which makes it
+suitable for long duration learning runs.
+The samples are ring buffers, the size of the definition's duration,
+that scroll across the raster.
+*/
+
+type ContinuousSim struct {
+	network.Host
+
+	// Sim ticks at 1ms resolution
+	dt float64
+	t  int
+
+	// How much history (ms) the samples retain.
+	window int
+}
+
+func init() {
+	simulation.Register("continuous", func() simulation.ISimulation {
+		return NewContinuousSim()
+	})
+}
+
+func NewContinuousSim() *ContinuousSim {
+	s := new(ContinuousSim)
+	s.EnableCheckpoints(s.save, s.restore)
+	s.Initialize("Continuous", s.create, s.tick, s.reset)
+	return s
+}
+
+func (s *ContinuousSim) create(synCnt int) *samples.Frame {
+	s.window = s.Def.Duration
+	s.t = 0
+	s.dt = 0.0
+
+	fmt.Printf("Syn cnt: %d, window: %d\n", synCnt, s.window)
+
+	return samples.NewFrame(synCnt, s.window, true)
+}
+
+// tick never resets, the sim runs until stopped.
+// It returns true each time a window's worth of samples has been
+// collected.
+func (s *ContinuousSim) tick() bool {
+	s.Net.Simulate(s.dt)
+	s.t++
+	s.dt += 1.0
+
+	return s.t%s.window == 0
+}
+
+// reset restarts the simulation from t = 0. It is only done on request.
+func (s *ContinuousSim) reset() {
+	s.t = 0
+	s.dt = 0.0
+	s.Net.Reset()
+}
+
+// continuousState is checkpointed along with the network.
+type continuousState struct {
+	T int `json:"t"`
+}
+
+func (s *ContinuousSim) save() (json.RawMessage, error) {
+	return json.Marshal(continuousState{T: s.t})
+}
+
+func (s *ContinuousSim) restore(data json.RawMessage) error {
+	var st continuousState
+	err := json.Unmarshal(data, &st)
+	if err != nil {
+		return err
+	}
+
+	s.t = st.T
+	s.dt = float64(st.T)
+	return nil
+}
@@ -0,0 +1,376 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State of a simulation's run loop.
+type State int
+
+const (
+	// Idle means nothing has been created yet.
+	Idle State = iota
+	// Created means the network exists but the loop isn't running.
+	Created
+	Running
+	Paused
+	// Stopping means the loop has been cancelled but hasn't exited yet.
+	Stopping
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "Idle"
+	case Created:
+		return "Created"
+	case Running:
+		return "Running"
+	case Paused:
+		return "Paused"
+	case Stopping:
+		return "Stopping"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Loop runs a simulation's tick function on a single goroutine.
+// Everything that touches the simulation (ticks, steps, resets and
+// property changes) is serialized by the Loop's mutex, so the App and
+// console goroutines can't mutate a simulation mid-tick.
+type Loop struct {
+	mutex sync.Mutex
+	// Signals a paused loop that it should resume or exit.
+	resume *sync.Cond
+
+	state State
+
+	// Pause once the current run (or window) completes.
+	pauseAtEnd bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// Sim ms per wall clock ms, 0 is as fast as possible.
+	speed float64
+	// Ticks are paced against an anchor rather than the previous
+	// tick so sleep overshoot doesn't accumulate.
+	anchor      time.Time
+	anchorTicks int64
+	reanchor    bool
+
+	// tick performs a single iteration and returns true
+	// when a run (or window) completed.
+	tick func() bool
+}
+
+// A loop that falls further behind than this (for example the tick is
+// slower than the requested speed) gives up catching up and re-anchors.
+const maxLag = time.Millisecond * 100
+
+const minSleep = time.Millisecond
+
+// NewLoop creates an Idle loop around tick.
+func NewLoop(tick func() bool) *Loop {
+	l := new(Loop)
+	l.resume = sync.NewCond(&l.mutex)
+	l.tick = tick
+	l.state = Idle
+	return l
+}
+
+// State returns the current state.
+func (l *Loop) State() State {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state
+}
+
+// Create runs create while the loop is not running and marks the loop Created.
+func (l *Loop) Create(create func()) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state != Idle && l.state != Created {
+		return fmt.Errorf("can't create while %s, stop first", l.state)
+	}
+
+	create()
+	l.state = Created
+	return nil
+}
+
+// Invalidate returns a stopped loop to Idle, for example after a new
+// definition was loaded and the network must be re-created. change,
+// if any, is called with the loop locked.
+func (l *Loop) Invalidate(change func()) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state != Idle && l.state != Created {
+		return fmt.Errorf("can't change the simulation while %s, stop first", l.state)
+	}
+
+	if change != nil {
+		change()
+	}
+	l.state = Idle
+	return nil
+}
+
+// Start launches the run loop. Only one loop can run at a time.
+func (l *Loop) Start(ctx context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	switch l.state {
+	case Idle:
+		return fmt.Errorf("nothing created yet")
+	case Running, Paused, Stopping:
+		return fmt.Errorf("already %s", l.state)
+	}
+
+	l.launch(ctx)
+	return nil
+}
+
+// RunPause runs until the current run (or window) completes and then
+// pauses. It either starts or resumes the loop.
+func (l *Loop) RunPause(ctx context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	switch l.state {
+	case Idle:
+		return fmt.Errorf("nothing created yet")
+	case Created:
+		l.pauseAtEnd = true
+		l.launch(ctx)
+	case Paused:
+		l.pauseAtEnd = true
+		l.state = Running
+		l.reanchor = true
+		l.resume.Broadcast()
+	default:
+		return fmt.Errorf("can't runPause while %s", l.state)
+	}
+
+	return nil
+}
+
+// launch must be called with the mutex held.
+func (l *Loop) launch(ctx context.Context) {
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+	l.state = Running
+	l.reanchor = true
+
+	go l.run(ctx, l.done)
+}
+
+// This runs in a "Go"routine.
+func (l *Loop) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for {
+		for l.state == Paused && ctx.Err() == nil {
+			l.resume.Wait()
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		if l.reanchor {
+			l.reanchor = false
+			l.anchor = time.Now()
+			l.anchorTicks = 0
+		}
+
+		complete := l.tick()
+		l.anchorTicks++
+
+		if complete && l.pauseAtEnd {
+			l.pauseAtEnd = false
+			l.state = Paused
+		}
+
+		// Give waiting commands a chance to get the lock between ticks,
+		// waiting until the next tick is due when paced. Short delays
+		// are left to accumulate because sleeps are coarse.
+		delay := l.delay()
+		l.mutex.Unlock()
+		if delay >= minSleep {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+		l.mutex.Lock()
+	}
+
+	l.state = Created
+}
+
+// delay returns how long to wait before the next tick is due.
+// It must be called with the mutex held.
+func (l *Loop) delay() time.Duration {
+	if l.speed <= 0 {
+		return 0
+	}
+
+	// Each tick is 1ms of sim time.
+	due := l.anchor.Add(time.Duration(float64(l.anchorTicks) * float64(time.Millisecond) / l.speed))
+	delay := time.Until(due)
+
+	if delay < -maxLag {
+		l.reanchor = true
+		return 0
+	}
+
+	return delay
+}
+
+// Speed returns the time scale, 0 means as fast as possible.
+func (l *Loop) Speed() float64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.speed
+}
+
+// SetSpeed sets the time scale: 1 is real-time (1ms of sim time per
+// ms), 2 is twice as fast, 0.5 half as fast and 0 as fast as possible.
+// It can be changed at any time.
+func (l *Loop) SetSpeed(speed float64) error {
+	if speed < 0 {
+		return fmt.Errorf("speed can't be negative (%g)", speed)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.speed = speed
+	l.reanchor = true
+	return nil
+}
+
+// ParseSpeed parses a speed argument relative to the current speed:
+// "max", "real", "faster", "slower" or a scale such as "10" or "0.5x".
+func ParseSpeed(current float64, arg string) (float64, error) {
+	switch arg {
+	case "max":
+		return 0, nil
+	case "real":
+		return 1, nil
+	case "faster":
+		if current <= 0 {
+			return 0, nil
+		}
+		return current * 2, nil
+	case "slower":
+		if current <= 0 {
+			// Slowest step down from max is real-time.
+			return 1, nil
+		}
+		return current / 2, nil
+	}
+
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(arg, "x"), 64)
+	if err != nil || speed < 0 {
+		return current, fmt.Errorf("invalid speed `%s`, expected max, real, faster, slower or a scale like 10x", arg)
+	}
+	return speed, nil
+}
+
+// SpeedString formats a speed for display, for example "max" or "2x".
+func SpeedString(speed float64) string {
+	if speed <= 0 {
+		return "max"
+	}
+	return strconv.FormatFloat(speed, 'g', 4, 64) + "x"
+}
+
+// Pause suspends a running loop.
+func (l *Loop) Pause() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state != Running {
+		return fmt.Errorf("can't pause while %s", l.state)
+	}
+
+	l.state = Paused
+	return nil
+}
+
+// Resume continues a paused loop.
+func (l *Loop) Resume() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state != Paused {
+		return fmt.Errorf("can't resume while %s", l.state)
+	}
+
+	l.pauseAtEnd = false
+	l.state = Running
+	l.reanchor = true
+	l.resume.Broadcast()
+	return nil
+}
+
+// Step makes a single tick while paused or stopped.
+func (l *Loop) Step() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state != Paused && l.state != Created {
+		return fmt.Errorf("can't step while %s, pause first", l.state)
+	}
+
+	l.tick()
+	return nil
+}
+
+// Stop cancels the loop and waits for it to exit. When Stop returns
+// the loop goroutine is gone and the state is Created.
+func (l *Loop) Stop() error {
+	l.mutex.Lock()
+
+	if l.state != Running && l.state != Paused {
+		l.mutex.Unlock()
+		return fmt.Errorf("not running (%s)", l.state)
+	}
+
+	l.state = Stopping
+	l.cancel()
+	l.resume.Broadcast()
+	done := l.done
+
+	l.mutex.Unlock()
+
+	<-done
+	return nil
+}
+
+// Do runs f while holding the loop's lock, i.e. between ticks.
+// It fails if nothing has been created yet.
+func (l *Loop) Do(f func()) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.state == Idle {
+		return fmt.Errorf("nothing created yet")
+	}
+
+	f()
+	return nil
+}
@@ -0,0 +1,156 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+)
+
+// loopIn returns a loop brought to state and its tick counter, read it
+// with the loop locked.
+func loopIn(t *testing.T, state State) (*Loop, *int) {
+	ticks := new(int)
+	l := NewLoop(func() bool {
+		*ticks++
+		return false
+	})
+	// Paced so a running loop doesn't spin.
+	l.SetSpeed(1)
+
+	if state == Idle {
+		return l, ticks
+	}
+	if err := l.Create(func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if state == Created {
+		return l, ticks
+	}
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if state == Paused {
+		if err := l.Pause(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l, ticks
+}
+
+func Test_LoopTransitions(t *testing.T) {
+	ops := map[string]func(l *Loop) error{
+		"step":       (*Loop).Step,
+		"stop":       (*Loop).Stop,
+		"pause":      (*Loop).Pause,
+		"resume":     (*Loop).Resume,
+		"invalidate": func(l *Loop) error { return l.Invalidate(nil) },
+		"start":      func(l *Loop) error { return l.Start(context.Background()) },
+		"runPause":   func(l *Loop) error { return l.RunPause(context.Background()) },
+	}
+
+	cases := []struct {
+		op   string
+		from State
+		ok   bool
+		to   State
+	}{
+		{"step", Idle, false, Idle},
+		{"step", Created, true, Created},
+		{"step", Running, false, Running},
+		{"step", Paused, true, Paused},
+
+		{"stop", Idle, false, Idle},
+		{"stop", Created, false, Created},
+		{"stop", Running, true, Created},
+		{"stop", Paused, true, Created},
+
+		{"pause", Idle, false, Idle},
+		{"pause", Created, false, Created},
+		{"pause", Running, true, Paused},
+		{"pause", Paused, false, Paused},
+
+		{"resume", Idle, false, Idle},
+		{"resume", Created, false, Created},
+		{"resume", Running, false, Running},
+		{"resume", Paused, true, Running},
+
+		{"start", Idle, false, Idle},
+		{"start", Created, true, Running},
+		{"start", Running, false, Running},
+		{"start", Paused, false, Paused},
+
+		{"runPause", Idle, false, Idle},
+		{"runPause", Created, true, Running},
+		{"runPause", Running, false, Running},
+		{"runPause", Paused, true, Running},
+
+		{"invalidate", Idle, true, Idle},
+		{"invalidate", Created, true, Idle},
+		{"invalidate", Running, false, Running},
+		{"invalidate", Paused, false, Paused},
+	}
+
+	for _, c := range cases {
+		l, _ := loopIn(t, c.from)
+
+		err := ops[c.op](l)
+		if (err == nil) != c.ok {
+			t.Errorf("%s from %s: error %v, expected success %v", c.op, c.from, err, c.ok)
+		}
+		if state := l.State(); state != c.to {
+			t.Errorf("%s from %s: state %s, expected %s", c.op, c.from, state, c.to)
+		}
+
+		if state := l.State(); state == Running || state == Paused {
+			if err = l.Stop(); err != nil {
+				t.Fatalf("%s from %s: stop: %v", c.op, c.from, err)
+			}
+		}
+	}
+}
+
+func Test_LoopStepTicksOnce(t *testing.T) {
+	for _, state := range []State{Created, Paused} {
+		l, ticks := loopIn(t, state)
+
+		var before, after int
+		l.Do(func() { before = *ticks })
+		if err := l.Step(); err != nil {
+			t.Fatalf("step from %s: %v", state, err)
+		}
+		l.Do(func() { after = *ticks })
+
+		if after != before+1 {
+			t.Errorf("step from %s ticked %d times", state, after-before)
+		}
+
+		if state == Paused {
+			l.Stop()
+		}
+	}
+}
+
+func Test_LoopInvalidateChange(t *testing.T) {
+	cases := []struct {
+		from    State
+		changed bool
+	}{
+		{Idle, true},
+		{Created, true},
+		{Running, false},
+		{Paused, false},
+	}
+
+	for _, c := range cases {
+		l, _ := loopIn(t, c.from)
+
+		changed := false
+		l.Invalidate(func() { changed = true })
+		if changed != c.changed {
+			t.Errorf("invalidate from %s: changed %v, expected %v", c.from, changed, c.changed)
+		}
+
+		if c.from == Running || c.from == Paused {
+			l.Stop()
+		}
+	}
+}
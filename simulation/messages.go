@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Status states sent back from a simulation.
+const (
+	StatusOk      = "ok"
+	StatusError   = "error"
+	StatusPong    = "pong"
+	StatusLoaded  = "loaded"
+	StatusStarted = "Started"
+	StatusRunning = "Running"
+	StatusPaused  = "Paused"
+	StatusStopped = "Stopped"
+)
+
+// Where commands come from, recorded with property changes.
+const (
+	SourceKey       = "key"
+	SourceConsole   = "console"
+	SourceScript    = "script"
+	SourceOptimizer = "optimizer"
+)
+
+// Command is a request sent to a simulation. Every command is answered
+// with exactly one Status carrying the same ID.
+type Command struct {
+	ID   int64
+	Name string
+	Args []string
+
+	// One of the Source constants, optional.
+	Source string
+}
+
+// From sets where the command came from.
+func (c Command) From(source string) Command {
+	c.Source = source
+	return c
+}
+
+// Status is sent back on the status channel. Replies to a Command carry
+// the command's ID, unsolicited status (for example progress while
+// running) has an ID of 0.
+type Status struct {
+	ID      int64
+	State   string
+	Message string
+	Err     error
+}
+
+// PropertyChange is sent on the property event channel whenever a
+// simulation property changes. ID is the Command that caused it.
+type PropertyChange struct {
+	ID       int64
+	Property string
+	Value    string
+}
+
+var lastID int64
+
+// NextID returns a unique request ID.
+func NextID() int64 {
+	return atomic.AddInt64(&lastID, 1)
+}
+
+// NewCommand creates a Command with a fresh ID.
+func NewCommand(name string, args ...string) Command {
+	return Command{ID: NextID(), Name: name, Args: args}
+}
+
+// ParseCommand splits a space separated command line, for example:
+// "prop Poisson Max 30"
+func ParseCommand(line string) Command {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return NewCommand("")
+	}
+	return NewCommand(fields[0], fields[1:]...)
+}
+
+// Reply creates the Status that answers c.
+func (c Command) Reply(state, message string) Status {
+	return Status{ID: c.ID, State: state, Message: message}
+}
+
+// Fail creates an error Status that answers c.
+func (c Command) Fail(err error) Status {
+	return Status{ID: c.ID, State: StatusError, Message: err.Error(), Err: err}
+}
+
+func (s Status) String() string {
+	if s.Message == "" {
+		return s.State
+	}
+	return s.State + ": " + s.Message
+}
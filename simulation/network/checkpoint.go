@@ -0,0 +1,260 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/wdevore/Deuron4/cell"
+	"github.com/wdevore/Deuron4/cell/stimulus"
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+// CheckpointVersion is incremented whenever the checkpoint format
+// changes incompatibly.
+const CheckpointVersion = 3
+
+// Checkpoint is a complete simulation saved to disk. The network is
+// rebuilt from the definition and then the state is applied, so
+// continuing from a checkpoint gives the same results as a run that
+// was never interrupted.
+type Checkpoint struct {
+	Version int `json:"version"`
+	// The simulation type's name, for example "RunReset".
+	Type       string             `json:"type"`
+	Definition *config.Definition `json:"definition"`
+	// The simulation type's own state, for example its time.
+	Sim     json.RawMessage    `json:"sim"`
+	Network NetworkState       `json:"network"`
+	Samples samples.FrameState `json:"samples"`
+}
+
+// NetworkState is everything in a Network that changes while running.
+type NetworkState struct {
+	Neuron      cell.NeuronState        `json:"neuron"`
+	Synapses    []cell.SynapseState     `json:"synapses"`
+	Connections []cell.ConnectionState  `json:"connections"`
+	Noise       []stimulus.PoissonState `json:"noise"`
+	// Noise streams of a definition with a rate or oscillation.
+	RateNoise []stimulus.RateState `json:"rateNoise,omitempty"`
+	// Noise streams of a regular or burst definition.
+	BurstNoise []stimulus.BurstState `json:"burstNoise,omitempty"`
+	Pattern    stimulus.PatternState `json:"pattern"`
+	// Seeds changed while running, the definition has the rest.
+	SeedOverrides map[string]int64 `json:"seedOverrides,omitempty"`
+}
+
+func (s *Network) Snapshot() NetworkState {
+	st := NetworkState{
+		Neuron:  s.neuron.(*cell.ProtoNeuron).Snapshot(),
+		Pattern: s.pattern1.(*stimulus.NPatternStream).Snapshot(),
+
+		SeedOverrides: s.seeds.Overrides(),
+	}
+
+	it := s.syns.Iterator()
+	for it.Next() {
+		st.Synapses = append(st.Synapses, it.Value().(*cell.ProtoSynapse).Snapshot())
+	}
+
+	it = s.cons.Iterator()
+	for it.Next() {
+		st.Connections = append(st.Connections, it.Value().(*cell.StraightConnection).Snapshot())
+	}
+
+	it = s.poiStreams.Iterator()
+	for it.Next() {
+		switch noise := it.Value().(type) {
+		case *stimulus.PoissonStream:
+			st.Noise = append(st.Noise, noise.Snapshot())
+		case *stimulus.RateStream:
+			st.RateNoise = append(st.RateNoise, noise.Snapshot())
+		case *stimulus.BurstStream:
+			st.BurstNoise = append(st.BurstNoise, noise.Snapshot())
+		}
+	}
+
+	return st
+}
+
+// Restore applies a snapshot to a network built from the same definition.
+func (s *Network) Restore(st NetworkState) error {
+	noise := len(st.Noise) + len(st.RateNoise) + len(st.BurstNoise)
+	if len(st.Synapses) != s.syns.Size() || len(st.Connections) != s.cons.Size() || noise != s.poiStreams.Size() {
+		return fmt.Errorf("checkpoint doesn't match the network: %d synapses, %d connections, %d noise streams",
+			len(st.Synapses), len(st.Connections), noise)
+	}
+
+	it := s.poiStreams.Iterator()
+	for it.Next() {
+		var states int
+		switch it.Value().(type) {
+		case *stimulus.PoissonStream:
+			states = len(st.Noise)
+		case *stimulus.RateStream:
+			states = len(st.RateNoise)
+		case *stimulus.BurstStream:
+			states = len(st.BurstNoise)
+		}
+		if states != noise {
+			return fmt.Errorf("checkpoint doesn't match the network: its noise streams are of another kind")
+		}
+	}
+
+	for name, seed := range st.SeedOverrides {
+		s.seeds.Override(name, seed)
+	}
+
+	s.neuron.(*cell.ProtoNeuron).Restore(st.Neuron)
+
+	it = s.syns.Iterator()
+	for it.Next() {
+		it.Value().(*cell.ProtoSynapse).Restore(st.Synapses[it.Index()])
+	}
+
+	it = s.cons.Iterator()
+	for it.Next() {
+		it.Value().(*cell.StraightConnection).Restore(st.Connections[it.Index()])
+	}
+
+	it = s.poiStreams.Iterator()
+	for it.Next() {
+		switch noise := it.Value().(type) {
+		case *stimulus.PoissonStream:
+			noise.Restore(st.Noise[it.Index()])
+		case *stimulus.RateStream:
+			noise.Restore(st.RateNoise[it.Index()])
+		case *stimulus.BurstStream:
+			noise.Restore(st.BurstNoise[it.Index()])
+		}
+	}
+
+	return s.pattern1.(*stimulus.NPatternStream).Restore(st.Pattern)
+}
+
+// EnableCheckpoints supplies how a simulation type saves and restores
+// its own state. Without it the Host refuses to save.
+func (h *Host) EnableCheckpoints(save func() (json.RawMessage, error), restore func(json.RawMessage) error) {
+	h.saveState = save
+	h.restoreState = restore
+}
+
+func (h *Host) path(name string) string {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(h.workingPath, name)
+	}
+	return name
+}
+
+// Save writes a checkpoint. It can be called while running, the
+// checkpoint is taken between ticks.
+func (h *Host) Save(name string) error {
+	if h.saveState == nil {
+		return fmt.Errorf("%s doesn't support checkpoints", h.name)
+	}
+
+	var cp *Checkpoint
+	var saveErr error
+
+	err := h.loop.Do(func() {
+		sim, err := h.saveState()
+		if err != nil {
+			saveErr = err
+			return
+		}
+
+		cp = &Checkpoint{
+			Version:    CheckpointVersion,
+			Type:       h.name,
+			Definition: h.Def,
+			Sim:        sim,
+			Network:    h.Net.Snapshot(),
+			Samples:    h.Samples().Back().Snapshot(),
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if saveErr != nil {
+		return saveErr
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(h.path(name), data, 0644)
+}
+
+// Restore re-creates the simulation from a checkpoint. The simulation
+// must be stopped.
+func (h *Host) Restore(name string) error {
+	if h.restoreState == nil {
+		return fmt.Errorf("%s doesn't support checkpoints", h.name)
+	}
+
+	data, err := ioutil.ReadFile(h.path(name))
+	if err != nil {
+		return err
+	}
+
+	cp := new(Checkpoint)
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	if cp.Version != CheckpointVersion {
+		return fmt.Errorf("%s: checkpoint version %d isn't supported, expected %d", name, cp.Version, CheckpointVersion)
+	}
+	if cp.Type != h.name {
+		return fmt.Errorf("%s: checkpoint is for a %s simulation, not %s", name, cp.Type, h.name)
+	}
+	if cp.Definition == nil {
+		return fmt.Errorf("%s: checkpoint has no definition", name)
+	}
+	if err = cp.Definition.Validate(); err != nil {
+		return fmt.Errorf("%s:\n%v", name, err)
+	}
+
+	// The definition must be in place before the network is re-created.
+	err = h.loop.Invalidate(func() {
+		h.Def = cp.Definition
+	})
+	if err != nil {
+		return err
+	}
+
+	err = h.Create()
+	if err != nil {
+		return err
+	}
+
+	var restoreErr error
+	err = h.loop.Do(func() {
+		if restoreErr = h.restoreState(cp.Sim); restoreErr != nil {
+			return
+		}
+		if restoreErr = h.Net.Restore(cp.Network); restoreErr != nil {
+			return
+		}
+		if restoreErr = h.Samples().Back().Restore(cp.Samples); restoreErr != nil {
+			return
+		}
+		h.publish()
+	})
+	if err != nil {
+		return err
+	}
+
+	if restoreErr != nil {
+		// Don't leave a half restored network around.
+		h.loop.Invalidate(nil)
+		return fmt.Errorf("%s: %v", name, restoreErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,521 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/pattern"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+// How often samples are published while running. Completed runs (or
+// windows) are always published.
+const publishInterval = time.Millisecond * 16
+
+// Host runs a Network on a simulation.Loop and handles the commands
+// common to every simulation type. Simulation types embed a Host and
+// supply how their samples are created, how the network is ticked
+// and what a reset means.
+type Host struct {
+	statusChannel    chan simulation.Status
+	propEventChannel chan simulation.PropertyChange
+
+	// For log output, for example "RunReset"
+	name string
+
+	loop *simulation.Loop
+	ctx  context.Context
+
+	workingPath string
+
+	// The loaded simulation definition.
+	Def *config.Definition
+
+	Net *Network
+
+	// Holds the *samples.Buffer, it is replaced on Create() while
+	// readers may be acquiring frames.
+	buffer    atomic.Value
+	published time.Time
+
+	// Hooks supplied by the simulation type.
+	// create returns the frame the network collects samples into.
+	create func(synCnt int) *samples.Frame
+	tick   func() bool
+	reset  func()
+
+	// Checkpoint hooks, see EnableCheckpoints.
+	saveState    func() (json.RawMessage, error)
+	restoreState func(json.RawMessage) error
+}
+
+// Initialize must be called by the simulation type's constructor.
+func (h *Host) Initialize(name string, create func(synCnt int) *samples.Frame, tick func() bool, reset func()) {
+	h.name = name
+	h.create = create
+	h.tick = tick
+	h.reset = reset
+	h.workingPath = "."
+	h.Def = config.Default()
+	h.ctx = context.Background()
+	h.loop = simulation.NewLoop(h.tickAndPublish)
+}
+
+func (h *Host) Connect(statusChannel chan simulation.Status, propEventChannel chan simulation.PropertyChange) {
+	// Send message back to the App/Viewer in the
+	// responseLoop coroutine.
+	h.statusChannel = statusChannel
+	h.propEventChannel = propEventChannel
+}
+
+// State returns the run loop's state.
+func (h *Host) State() simulation.State {
+	return h.loop.State()
+}
+
+func (h *Host) Command(cmd simulation.Command) {
+	// fmt.Printf("Command: %v\n", cmd)
+	var err error
+	state := simulation.StatusOk
+
+	switch cmd.Name {
+	case "ping":
+		state = simulation.StatusPong
+	case "state":
+		// The reply's message carries the loop state.
+	case "load":
+		// An optional json file relative to the working directory.
+		// Without one the current definition is used.
+		if len(cmd.Args) > 0 && cmd.Args[0] != "" {
+			err = h.Load(cmd.Args[0])
+			if err != nil {
+				fmt.Printf("%s: failed to load `%s`:\n%v\n", h.name, cmd.Args[0], err)
+			}
+		}
+		state = simulation.StatusLoaded
+	case "start":
+		if h.loop.State() == simulation.Idle {
+			err = h.Create()
+		}
+		if err == nil {
+			fmt.Println("Starting...")
+			err = h.loop.Start(h.ctx)
+		}
+		state = simulation.StatusStarted
+	case "stop":
+		// Replies once the run loop has exited.
+		err = h.loop.Stop()
+		if err == nil {
+			fmt.Printf("%s: run loop exited\n", h.name)
+		}
+		state = simulation.StatusStopped
+	case "pause":
+		// Toggles between paused and running.
+		if h.loop.State() == simulation.Paused {
+			err = h.loop.Resume()
+			state = simulation.StatusRunning
+		} else {
+			err = h.loop.Pause()
+			state = simulation.StatusPaused
+		}
+	case "resume":
+		err = h.loop.Resume()
+		state = simulation.StatusRunning
+	case "step":
+		err = h.Step()
+	case "runPause":
+		err = h.RunPause()
+	case "reset":
+		err = h.Reset()
+	case "save":
+		if len(cmd.Args) < 1 {
+			err = fmt.Errorf("usage: save file")
+			break
+		}
+		err = h.Save(cmd.Args[0])
+		if err == nil {
+			fmt.Printf("%s: saved `%s`\n", h.name, cmd.Args[0])
+		}
+	case "restore":
+		if len(cmd.Args) < 1 {
+			err = fmt.Errorf("usage: restore file")
+			break
+		}
+		err = h.Restore(cmd.Args[0])
+		if err == nil {
+			fmt.Printf("%s: restored `%s`\n", h.name, cmd.Args[0])
+		}
+	case "seed":
+		// seed [stream seed], without arguments the seeds are listed.
+		if len(cmd.Args) == 1 || len(cmd.Args) > 2 {
+			err = fmt.Errorf("usage: seed [stream seed]")
+			break
+		}
+		var msg string
+		msg, err = h.seed(cmd.Args)
+		if err == nil {
+			go h.respond(cmd.Reply(simulation.StatusOk, msg))
+			return
+		}
+	case "speed":
+		// Without an argument the current speed is replied.
+		speed := h.loop.Speed()
+		if len(cmd.Args) > 0 {
+			speed, err = simulation.ParseSpeed(speed, cmd.Args[0])
+			if err == nil {
+				err = h.loop.SetSpeed(speed)
+			}
+		}
+		if err == nil {
+			go h.respond(cmd.Reply(simulation.StatusOk, simulation.SpeedString(speed)))
+			return
+		}
+	case "props":
+		// Lists every property with its value, units and range, or
+		// the value of each element of a property: props Poisson Max@exc
+		var list string
+		var listErr error
+		err = h.loop.Do(func() {
+			if len(cmd.Args) == 0 {
+				list = h.Net.Properties().List()
+			} else {
+				list, listErr = h.Net.Properties().Elements(strings.Join(cmd.Args, " "))
+			}
+		})
+		if err == nil {
+			err = listErr
+		}
+		if err == nil {
+			go h.respond(cmd.Reply(simulation.StatusOk, list))
+			return
+		}
+	case "undo", "redo":
+		var change *property.Change
+		var propErr error
+		err = h.loop.Do(func() {
+			if cmd.Name == "undo" {
+				change, propErr = h.Net.Undo(cmd.ID, cmd.Source)
+			} else {
+				change, propErr = h.Net.Redo(cmd.ID, cmd.Source)
+			}
+		})
+		if err == nil {
+			err = propErr
+		}
+		if err == nil {
+			go h.respond(cmd.Reply(simulation.StatusOk, change.String()))
+			return
+		}
+	case "history":
+		// history [n] lists the last n property changes, or all.
+		n := 0
+		if len(cmd.Args) > 0 {
+			if n, err = strconv.Atoi(cmd.Args[0]); err != nil {
+				err = fmt.Errorf("usage: history [count]")
+				break
+			}
+		}
+		var list string
+		err = h.loop.Do(func() {
+			list = h.Net.History().List(n)
+		})
+		if err == nil {
+			if list == "" {
+				list = "no changes"
+			}
+			go h.respond(cmd.Reply(simulation.StatusOk, list))
+			return
+		}
+	case "patterns":
+		go h.respond(cmd.Reply(simulation.StatusOk, h.patterns()))
+		return
+	case "pattern":
+		// pattern save name, saves the definition's pattern into
+		// the library. pattern generate name spec-file, generates
+		// a pattern, or a family of them, into the library.
+		var msg string
+		msg, err = h.pattern(cmd.Args)
+		if err == nil {
+			go h.respond(cmd.Reply(simulation.StatusOk, msg))
+			return
+		}
+	case "prop":
+		var propErr error
+		err = h.loop.Do(func() {
+			propErr = h.Net.ChangeProperty(cmd.ID, cmd.Source, cmd.Args)
+		})
+		if err == nil {
+			err = propErr
+		}
+	default:
+		err = fmt.Errorf("unknown command `%s`", cmd.Name)
+	}
+
+	if err != nil {
+		go h.respond(cmd.Fail(err))
+		return
+	}
+
+	go h.respond(cmd.Reply(state, h.loop.State().String()))
+}
+
+// Sends a status back through channel
+func (h *Host) respond(status simulation.Status) {
+	h.statusChannel <- status
+}
+
+// Load reads a simulation definition. The definition takes effect
+// on the next Create().
+func (h *Host) Load(name string) error {
+	def, err := config.Load(h.path(name))
+	if err != nil {
+		return err
+	}
+
+	// The network must be re-created to use the new definition.
+	return h.loop.Invalidate(func() {
+		h.Def = def
+	})
+}
+
+// patterns lists the pattern library.
+func (h *Host) patterns() string {
+	library := pattern.NewLibrary(h.workingPath)
+
+	lines := []string{}
+	for _, name := range library.Names() {
+		p, err := library.Find(name)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d streams, %dms", name, len(p.Streams), p.Length))
+	}
+
+	if len(lines) == 0 {
+		return "no patterns in " + pattern.LibraryDir
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (h *Host) pattern(args []string) (string, error) {
+	library := pattern.NewLibrary(h.workingPath)
+
+	switch {
+	case len(args) == 2 && args[0] == "save":
+		p := pattern.FromDefinition(args[1], h.Def.Pattern.Streams)
+		if err := library.Save(p); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("saved pattern `%s`", p.Name), nil
+	case len(args) == 3 && args[0] == "generate":
+		spec, err := pattern.LoadSpec(h.path(args[2]))
+		if err != nil {
+			return "", err
+		}
+		family, err := pattern.Generate(args[1], spec)
+		if err != nil {
+			return "", err
+		}
+
+		lines := []string{}
+		for i, p := range family {
+			if err = library.Save(p); err != nil {
+				return "", err
+			}
+			line := fmt.Sprintf("saved pattern `%s`", p.Name)
+			if i > 0 {
+				line += fmt.Sprintf(", overlap with `%s` %0.2f", family[0].Name, pattern.Overlap(p, family[0]))
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	return "", fmt.Errorf("usage: pattern save name | pattern generate name spec-file")
+}
+
+// Seeds returns the seed of every random stream.
+func (h *Host) Seeds() map[string]int64 {
+	seeds := map[string]int64{}
+	h.loop.Do(func() {
+		seeds = h.Net.Seeds().Seeds()
+	})
+	return seeds
+}
+
+// seed lists the seeds, or re-seeds a stream (or group of streams)
+// given as: stream seed
+func (h *Host) seed(args []string) (string, error) {
+	var msg string
+	var seedErr error
+
+	err := h.loop.Do(func() {
+		seeds := h.Net.Seeds()
+
+		if len(args) == 2 {
+			seed, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				seedErr = fmt.Errorf("invalid seed `%s`", args[1])
+				return
+			}
+			if seedErr = seeds.Reseed(args[0], seed); seedErr != nil {
+				return
+			}
+		}
+
+		var s strings.Builder
+		fmt.Fprintf(&s, "master: %d", seeds.Master())
+		for _, name := range seeds.Names() {
+			fmt.Fprintf(&s, "\n%s: %d", name, seeds.Seed(name))
+		}
+		msg = s.String()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return msg, seedErr
+}
+
+// Definition returns the loaded definition.
+func (h *Host) Definition() *config.Definition {
+	return h.Def
+}
+
+// Create builds the network. It fails if the run loop is running or
+// the definition names an unknown property.
+func (h *Host) Create() error {
+	var propErr error
+
+	err := h.loop.Create(func() {
+		fmt.Println("Creating...")
+
+		h.Net = NewNetwork(h.statusChannel, h.propEventChannel)
+		synCnt := h.Net.Initialize(h.Def)
+
+		for _, name := range property.Preset(h.Def.Properties).Names() {
+			if propErr = h.Net.Properties().Set(name, h.Def.Properties[name]); propErr != nil {
+				propErr = fmt.Errorf("$.properties: %v", propErr)
+				break
+			}
+		}
+
+		buffer := samples.NewBuffer(h.create(synCnt))
+		h.Net.SetSamples(buffer.Back())
+		h.buffer.Store(buffer)
+
+		fmt.Println("Launched.")
+	})
+	if err != nil {
+		return err
+	}
+
+	if propErr != nil {
+		// Don't leave a network without its properties around.
+		h.loop.Invalidate(nil)
+	}
+
+	return propErr
+}
+
+// Samples returns the buffer the renderer acquires frames from, nil
+// if nothing has been created yet.
+func (h *Host) Samples() *samples.Buffer {
+	buffer, _ := h.buffer.Load().(*samples.Buffer)
+	return buffer
+}
+
+// Tick is a single iteration of the run loop. It must not be called
+// while the loop is running, use Step instead.
+func (h *Host) Tick() bool {
+	return h.tickAndPublish()
+}
+
+func (h *Host) tickAndPublish() bool {
+	complete := h.tick()
+
+	if complete || time.Since(h.published) >= publishInterval {
+		h.publish()
+	}
+
+	return complete
+}
+
+func (h *Host) publish() {
+	h.Samples().Publish()
+	h.published = time.Now()
+}
+
+// Step makes a single tick while paused or stopped.
+func (h *Host) Step() error {
+	err := h.loop.Step()
+	if err != nil {
+		return err
+	}
+	// Show the step even if it was within the publish interval.
+	return h.loop.Do(h.publish)
+}
+
+// RunPause runs to the end of the current run (or window) then pauses.
+func (h *Host) RunPause() error {
+	return h.loop.RunPause(h.ctx)
+}
+
+// Reset is performed between ticks.
+func (h *Host) Reset() error {
+	return h.loop.Do(func() {
+		h.reset()
+		h.publish()
+	})
+}
+
+// Snapshot captures every property's value, for presets.
+func (h *Host) Snapshot() (property.Preset, error) {
+	var preset property.Preset
+	err := h.loop.Do(func() {
+		preset = h.Net.Properties().Snapshot()
+	})
+	return preset, err
+}
+
+// Diff describes how a preset differs from the current values, see
+// property.Registry.Diff.
+func (h *Host) Diff(preset property.Preset) ([]string, error) {
+	var lines []string
+	err := h.loop.Do(func() {
+		lines = h.Net.Properties().Diff(preset)
+	})
+	return lines, err
+}
+
+// Properties returns the created network's properties, nil before
+// Create. While running use the prop and props commands instead.
+func (h *Host) Properties() *property.Registry {
+	if h.Net == nil {
+		return nil
+	}
+	return h.Net.Properties()
+}
+
+func (h *Host) RequestProperty(property string) string {
+	value := ""
+	h.loop.Do(func() {
+		value = h.Net.RequestProperty(property)
+	})
+	return value
+}
+
+func (h *Host) SetCommand(cmd []string) {
+	h.loop.Do(func() {
+		h.Net.SetCommand(cmd)
+	})
+}
@@ -0,0 +1,820 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	sll "github.com/emirpasic/gods/lists/singlylinkedlist"
+	"github.com/wdevore/Deuron4/cell"
+	"github.com/wdevore/Deuron4/cell/stimulus"
+	"github.com/wdevore/Deuron4/deuron/dist"
+	"github.com/wdevore/Deuron4/deuron/rng"
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+// Network is a single neuron driven by Poisson noise and a stimulus
+// pattern. The simulation types (runreset, continuous) drive it.
+type Network struct {
+	channel          chan simulation.Status
+	propEventChannel chan simulation.PropertyChange
+
+	neuron cell.ICell
+
+	poiStreams  *sll.List
+	stimStreams *sll.List
+	syns        *sll.List
+	cons        *sll.List
+
+	properties *property.Registry
+	// The property up/down nudges.
+	active string
+	// Every property change, for undo/redo.
+	history *property.History
+
+	// Noise streams [0, excitatory) drive excitatory synapses.
+	excitatory int
+	// Synapse (and noise stream) IDs by tag.
+	tags map[string][]int
+
+	pattern1 stimulus.IPatternContainer
+
+	// Every random stream is derived from the definition's master seed.
+	seeds *rng.Manager
+
+	// The working frame samples are collected into.
+	samples *samples.Frame
+}
+
+func NewNetwork(channel chan simulation.Status, propEventChannel chan simulation.PropertyChange) *Network {
+	s := new(Network)
+	s.channel = channel
+	s.propEventChannel = propEventChannel
+	return s
+}
+
+// SetSamples sets the frame that diagnostics are collected into.
+func (s *Network) SetSamples(frame *samples.Frame) {
+	s.samples = frame
+}
+
+func (s *Network) Initialize(def *config.Definition) int {
+	neuron := cell.NewProtoNeuron()
+	neuron.(*cell.ProtoNeuron).Configure(
+		def.Neuron.Threshold, def.Neuron.APDecay, def.Neuron.MaxAP,
+		def.Neuron.TaoP, def.Neuron.TaoN, def.Neuron.TaoY)
+	s.neuron = neuron
+
+	// A neuron has a dendrite
+	den := cell.NewProtoDendrite(s.neuron)
+
+	comp := cell.NewProtoCompartment(den)
+
+	// Split synapses into Excite and Inhibit, typically 80%/20%
+	synCount := def.Synapses.Count
+	excite := int(float64(synCount) * def.Synapses.ExcitatoryRatio)
+	inhibit := synCount - excite
+
+	s.poiStreams = sll.New()
+	s.stimStreams = sll.New()
+	s.syns = sll.New()
+	s.cons = sll.New()
+
+	synId := 0
+	poiId := 0
+
+	s.excitatory = excite
+	s.tags = map[string][]int{}
+	for tag, ids := range def.Synapses.Tags {
+		// Validated with the definition.
+		s.tags[tag], _ = property.ParseIDs(ids, synCount)
+	}
+
+	s.seeds = rng.NewManager(def.Seeds.Master)
+	for name, seed := range def.Seeds.Streams {
+		s.seeds.Override(name, seed)
+	}
+
+	s.createPatterns(def)
+	// Every synapse receives pattern stream 0 unless the streams are
+	// routed one per synapse.
+	each := def.Synapses.Routing == config.RoutingEach
+	moreStims := s.pattern1.Begin()
+
+	// For each synapse we attach a connection.
+	// For this simulation each connection is also connected to
+	// a poisson and pattern stream.
+	for i := 0; i < excite; i++ {
+		syn := cell.NewProtoSynapse(comp, cell.Excititory, synId)
+		s.syns.Add(syn)
+
+		con := cell.NewStraightConnection()
+		s.cons.Add(con)
+
+		poi := s.createNoise(def, poiId)
+
+		// Collect streams so we can step() it later.
+		s.poiStreams.Add(poi)
+
+		// Connect the pieces together:
+		// stream -> connection -> synapse
+
+		poi.Attach(con) // route noise stream into connection
+
+		if moreStims {
+			stim := s.pattern1.Stream()
+			s.stimStreams.Add(stim)
+			stim.Attach(con) // route stimulus into connection
+			if each {
+				moreStims = s.pattern1.Next()
+			}
+		}
+
+		syn.Connect(con) // route connection to synapse
+
+		synId++
+		poiId++
+	}
+
+	for i := 0; i < inhibit; i++ {
+		syn := cell.NewProtoSynapse(comp, cell.Inhibitory, synId)
+		s.syns.Add(syn)
+
+		con := cell.NewStraightConnection()
+		s.cons.Add(con)
+
+		poi := s.createNoise(def, poiId)
+
+		s.poiStreams.Add(poi)
+		// Connect stream to input of connection
+		poi.Attach(con)
+
+		if moreStims {
+			stim := s.pattern1.Stream()
+			s.stimStreams.Add(stim)
+			stim.Attach(con) // route stimulus into connection
+			if each {
+				moreStims = s.pattern1.Next()
+			}
+		}
+
+		syn.Connect(con) // attach connection into synapse
+
+		synId++
+		poiId++
+	}
+
+	s.neuron.AttachDendrite(den)
+
+	s.registerProperties(def)
+
+	fmt.Println("Sim: initialized")
+
+	return synCount
+}
+
+// Seeds returns the manager of the network's random streams.
+func (s *Network) Seeds() *rng.Manager {
+	return s.seeds
+}
+
+func (s *Network) Reset() {
+	it := s.poiStreams.Iterator()
+	for it.Next() {
+		poi := it.Value().(stimulus.IPatternStream)
+		poi.Reset()
+	}
+
+	// Reset stimulus
+	s.pattern1.Reset()
+
+	// The samples restart from t = 0.
+	s.samples.ClearChanges()
+	s.samples.ClearPresentations()
+}
+
+// Simulate makes a single pass of a simulation.
+func (s *Network) Simulate(t float64) {
+	// fmt.Printf("Pass: %f\n", t)
+	s.pre()
+
+	s.diagnostics(t) // Collect samples
+
+	// Update learning rules (STDP and BTSP) and internal states/properties
+	s.neuron.Process()
+
+	// Now integrate
+	epsp := s.neuron.Integrate(t)
+
+	s.post()
+
+	// Update app state.
+	msg := fmt.Sprintf("(%d) epsp:(%f)...", int(t), epsp)
+
+	// Update the app thread with a message. Progress is dropped rather
+	// than blocking the sim when the App is busy.
+	s.progress(simulation.Status{State: simulation.StatusRunning, Message: msg})
+
+	// time.Sleep(time.Millisecond * 100)
+}
+
+func (s *Network) pre() {
+	// Prep: Update streams first
+	it := s.poiStreams.Iterator()
+	for it.Next() {
+		poi := it.Value().(stimulus.IPatternStream)
+		poi.Step()
+	}
+
+	// Step all the stimulus streams
+	s.pattern1.Step()
+}
+
+func (s *Network) diagnostics(t float64) {
+	// Capture the state at time "t".
+
+	// Collect noise samples from the poisson streams.
+	it := s.poiStreams.Iterator()
+	for it.Next() {
+		pois := it.Value().(stimulus.IPatternStream)
+		s.samples.Poi.Put(t, pois.Output(), pois.Id(), 3)
+	}
+
+	if s.pattern1.Onset() {
+		label := s.pattern1.Label()
+		if label == "" {
+			label = "pattern"
+		}
+		s.samples.Present(t, label)
+	}
+
+	if s.pattern1.Begin() {
+		more := true
+		for more {
+			stim := s.pattern1.Stream()
+			s.samples.Stim.Put(t, stim.Output(), stim.Id(), 4)
+			more = s.pattern1.Next()
+		}
+	}
+
+	// Capture the cell's current output
+	s.samples.Cell.Put(t, s.neuron.Output(), s.neuron.ID(), 0)
+	s.samples.Put(t)
+}
+
+func (s *Network) post() {
+	// Post is a preperation for next pass.
+	// This means we put all Data, on the output side of a connection,
+	// back into the pool.
+
+	// The values either source from noise streams, stimulus or other neuron outputs.
+	it := s.cons.Iterator()
+	for it.Next() {
+		con := it.Value().(cell.IConnection)
+		con.Post()
+	}
+}
+
+// progress sends status only if someone is ready to receive it.
+func (s *Network) progress(status simulation.Status) {
+	select {
+	case s.channel <- status:
+	default:
+	}
+}
+
+func (s *Network) propertyChangeEvent(id int64, property, value string) {
+	// Send message back to the App. Property setters run with the loop
+	// locked, so the event is dropped rather than blocking the sim when
+	// nobody is receiving (or connected).
+	select {
+	case s.propEventChannel <- simulation.PropertyChange{ID: id, Property: property, Value: value}:
+	default:
+	}
+}
+
+// registerProperties makes the network's tunable parameters available
+// by name. Defaults are the definition's values.
+func (s *Network) registerProperties(def *config.Definition) {
+	s.properties = property.NewRegistry()
+	s.history = property.NewHistory(s.properties)
+
+	s.registerNoiseProperties(def)
+
+	// Pattern perturbations take effect on the next presentation.
+	perturb := s.pattern1.Perturbation()
+	s.properties.Register(&property.Property{
+		Name: "Pattern Jitter", Units: "ms", Min: 0.0, Max: 100.0, Step: 0.5,
+		Default: def.Pattern.Perturb.Jitter,
+		Get:     func() float64 { return perturb.Jitter },
+		Set:     func(v float64) { perturb.Jitter = v },
+	})
+	s.properties.Register(&property.Property{
+		Name: "Pattern Deletion", Min: 0.0, Max: 1.0, Step: 0.05,
+		Default: def.Pattern.Perturb.Deletion,
+		Get:     func() float64 { return perturb.Deletion },
+		Set:     func(v float64) { perturb.Deletion = v },
+	})
+	s.properties.Register(&property.Property{
+		Name: "Pattern Insertion", Units: "Hz", Min: 0.0, Max: 1000.0, Step: 1.0,
+		Default: def.Pattern.Perturb.Insertion,
+		Get:     func() float64 { return perturb.Insertion },
+		Set:     func(v float64) { perturb.Insertion = v },
+	})
+}
+
+// Properties returns the network's tunable parameters.
+func (s *Network) Properties() *property.Registry {
+	return s.properties
+}
+
+func (s *Network) RequestProperty(name string) string {
+	value, err := s.properties.Value(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// SetCommand makes a property, given as <property...> <value>, the
+// active one that up/down nudges.
+func (s *Network) SetCommand(cmd []string) {
+	name, _, err := property.Split(cmd)
+	if err == nil {
+		s.active = name
+	}
+}
+
+// registerNoiseProperties registers the properties of the kind of noise
+// stream the definition asks for. They have a value per noise stream.
+func (s *Network) registerNoiseProperties(def *config.Definition) {
+	switch {
+	case def.Stream != nil && def.Stream.Type != config.StreamOscillation:
+		burst := func(get func(*stimulus.BurstStream) int, set func(*stimulus.BurstStream, int)) property.IGroup {
+			return &noiseGroup{net: s,
+				get: func(st stimulus.IPatternStream) float64 { return float64(get(st.(*stimulus.BurstStream))) },
+				set: func(st stimulus.IPatternStream, v float64) { set(st.(*stimulus.BurstStream), int(v)) },
+			}
+		}
+
+		s.properties.Register(&property.Property{
+			Name: "Stream Period", Type: property.Int, Units: "ms", Min: 1.0, Max: 10000.0, Step: 5.0,
+			Default: float64(def.Stream.Period),
+			Group:   burst((*stimulus.BurstStream).Period, (*stimulus.BurstStream).SetPeriod),
+		})
+		if def.Stream.Type == config.StreamBurst {
+			s.properties.Register(&property.Property{
+				Name: "Burst Spikes", Type: property.Int, Min: 1.0, Max: 100.0, Step: 1.0,
+				Default: float64(def.Stream.Spikes),
+				Group:   burst((*stimulus.BurstStream).Spikes, (*stimulus.BurstStream).SetSpikes),
+			})
+			s.properties.Register(&property.Property{
+				Name: "Burst Interval", Type: property.Int, Units: "ms", Min: 1.0, Max: 1000.0, Step: 1.0,
+				Default: float64(def.Stream.Interval),
+				Group:   burst((*stimulus.BurstStream).Interval, (*stimulus.BurstStream).SetInterval),
+			})
+		}
+	case def.Rate != nil || def.Stream != nil:
+		var refractory float64
+		if def.Rate != nil {
+			refractory = def.Rate.Refractory
+		} else {
+			refractory = def.Stream.Refractory
+		}
+
+		rate := func(get func(*stimulus.RateStream) float64, set func(*stimulus.RateStream, float64)) property.IGroup {
+			return &noiseGroup{net: s,
+				get: func(st stimulus.IPatternStream) float64 { return get(st.(*stimulus.RateStream)) },
+				set: func(st stimulus.IPatternStream, v float64) { set(st.(*stimulus.RateStream), v) },
+			}
+		}
+
+		s.properties.Register(&property.Property{
+			Name: "Noise Rate Scale", Min: 0.0, Max: 100.0, Step: 0.1,
+			Default: 1.0,
+			Group:   rate((*stimulus.RateStream).Scale, (*stimulus.RateStream).SetScale),
+		})
+		s.properties.Register(&property.Property{
+			Name: "Noise Refractory", Units: "ms", Min: 0.0, Max: 100.0, Step: 0.5,
+			Default: refractory,
+			Group:   rate((*stimulus.RateStream).Refractory, (*stimulus.RateStream).SetRefractory),
+		})
+
+		if def.Stream != nil {
+			s.registerOscillationProperties(def.Stream)
+		}
+	default:
+		noise := func(get func(*stimulus.PoissonStream) float64, set func(*stimulus.PoissonStream, float64)) property.IGroup {
+			return &noiseGroup{net: s,
+				get: func(st stimulus.IPatternStream) float64 { return get(st.(*stimulus.PoissonStream)) },
+				set: func(st stimulus.IPatternStream, v float64) { set(st.(*stimulus.PoissonStream), v) },
+			}
+		}
+
+		s.properties.Register(&property.Property{
+			Name: "Poisson Min", Units: "ms", Min: 0.0, Max: 1000.0, Step: 1.0,
+			Default: def.Poisson.Min,
+			Group:   noise((*stimulus.PoissonStream).Min, (*stimulus.PoissonStream).SetMin),
+		})
+
+		isi := def.Poisson.ISI
+		if isi == nil {
+			s.properties.Register(&property.Property{
+				Name: "Poisson Max", Units: "ms", Min: 1.0, Max: 10000.0, Step: 10.0,
+				Default: def.Poisson.Max,
+				Group:   noise((*stimulus.PoissonStream).Max, (*stimulus.PoissonStream).SetMax),
+			})
+			s.properties.Register(&property.Property{
+				Name: "Poisson Spread", Min: 1.0, Max: 1000.0, Step: 5.0,
+				Default: def.Poisson.Spread,
+				Group:   noise((*stimulus.PoissonStream).Spread, (*stimulus.PoissonStream).SetSpread),
+			})
+		} else {
+			s.properties.Register(&property.Property{
+				Name: "Poisson ISI Mean", Units: "ms", Min: 1.0, Max: 10000.0, Step: 5.0,
+				Default: isi.Mean,
+				Group: noise(
+					func(poi *stimulus.PoissonStream) float64 { return poi.Distribution().Mean() },
+					func(poi *stimulus.PoissonStream, v float64) { poi.Distribution().SetMean(v) }),
+			})
+			if isi.Type == config.ISIGamma {
+				// The regularity, the ISI CV is 1/sqrt(shape).
+				s.properties.Register(&property.Property{
+					Name: "Poisson ISI Shape", Min: 0.1, Max: 100.0, Step: 0.5,
+					Default: isi.Shape,
+					Group: noise(
+						func(poi *stimulus.PoissonStream) float64 { return poi.Distribution().(*dist.GammaISI).Shape() },
+						func(poi *stimulus.PoissonStream, v float64) { poi.Distribution().(*dist.GammaISI).SetShape(v) }),
+				})
+			}
+		}
+	}
+}
+
+// registerOscillationProperties registers the phase preferences and
+// lockings of oscillation streams.
+func (s *Network) registerOscillationProperties(def *config.StreamDef) {
+	osc := func(value func(*stimulus.OscillationRate) *float64) property.IGroup {
+		return &noiseGroup{net: s,
+			get: func(st stimulus.IPatternStream) float64 {
+				return *value(st.(*stimulus.RateStream).Rate().(*stimulus.OscillationRate))
+			},
+			set: func(st stimulus.IPatternStream, v float64) {
+				*value(st.(*stimulus.RateStream).Rate().(*stimulus.OscillationRate)) = v
+			},
+		}
+	}
+
+	s.properties.Register(&property.Property{
+		Name: "Theta Phase", Units: "deg", Min: 0.0, Max: 360.0, Step: 15.0,
+		Default: def.ThetaPhase,
+		Group:   osc(func(r *stimulus.OscillationRate) *float64 { return &r.ThetaPhase }),
+	})
+	s.properties.Register(&property.Property{
+		Name: "Theta Locking", Min: 0.0, Max: 50.0, Step: 0.25,
+		Default: def.ThetaLocking,
+		Group:   osc(func(r *stimulus.OscillationRate) *float64 { return &r.ThetaLocking }),
+	})
+	s.properties.Register(&property.Property{
+		Name: "Gamma Phase", Units: "deg", Min: 0.0, Max: 360.0, Step: 15.0,
+		Default: def.GammaPhase,
+		Group:   osc(func(r *stimulus.OscillationRate) *float64 { return &r.GammaPhase }),
+	})
+	s.properties.Register(&property.Property{
+		Name: "Gamma Locking", Min: 0.0, Max: 50.0, Step: 0.25,
+		Default: def.GammaLocking,
+		Group:   osc(func(r *stimulus.OscillationRate) *float64 { return &r.GammaLocking }),
+	})
+}
+
+// ChangeProperty handles the arguments of a "prop" command, either:
+//   <property> <value>, for example: Poisson Max 30
+//   up|down [amount], which nudges the active property by amount or
+//   by the property's step.
+// id is the request that caused the change and source where it came
+// from, both are recorded in the history.
+func (s *Network) ChangeProperty(id int64, source string, args []string) error {
+	if len(args) > 0 && (args[0] == "up" || args[0] == "down") {
+		if s.active == "" {
+			return fmt.Errorf("no active property to move %s", args[0])
+		}
+
+		p, err := s.properties.Find(s.active)
+		if err != nil {
+			return err
+		}
+
+		delta := p.Step
+		if len(args) > 1 {
+			if delta, err = strconv.ParseFloat(args[1], 64); err != nil {
+				return fmt.Errorf("%s amount `%s` isn't a number", args[0], args[1])
+			}
+		}
+		if args[0] == "down" {
+			delta = -delta
+		}
+
+		change, err := s.history.Nudge(s.active, delta, source, s.samples.Time)
+		if err != nil {
+			return err
+		}
+		s.changed(id, change)
+		return nil
+	}
+
+	name, text, err := property.Split(args)
+	if err != nil {
+		return fmt.Errorf("expected <property> <value> or up|down [amount], got %v", args)
+	}
+
+	p, err := s.properties.Find(name)
+	if err != nil {
+		return err
+	}
+	value, err := p.Parse(text)
+	if err != nil {
+		return err
+	}
+
+	change, err := s.history.Set(name, value, source, s.samples.Time)
+	if err != nil {
+		return err
+	}
+
+	s.active = name
+	s.changed(id, change)
+
+	return nil
+}
+
+// Undo reverts the last property change, see property.History.
+func (s *Network) Undo(id int64, source string) (*property.Change, error) {
+	change, err := s.history.Undo(source, s.samples.Time)
+	if err != nil {
+		return nil, err
+	}
+	s.changed(id, change)
+	return change, nil
+}
+
+// Redo re-applies the last undone property change.
+func (s *Network) Redo(id int64, source string) (*property.Change, error) {
+	change, err := s.history.Redo(source, s.samples.Time)
+	if err != nil {
+		return nil, err
+	}
+	s.changed(id, change)
+	return change, nil
+}
+
+// History returns every property change since the network was created.
+func (s *Network) History() *property.History {
+	return s.history
+}
+
+// changed stamps a change into the samples and tells the App.
+func (s *Network) changed(id int64, change *property.Change) {
+	value, _ := s.properties.Value(change.Property)
+	s.samples.Mark(change.Property, value, change.Source)
+	s.propertyChangeEvent(id, change.Property, value)
+}
+
+// noiseGroup addresses the noise streams by ID (e.g. 3 or 0-3,7),
+// `exc`, `inh` or tag for a noise property.
+type noiseGroup struct {
+	net *Network
+	get func(stimulus.IPatternStream) float64
+	set func(stimulus.IPatternStream, float64)
+}
+
+func (g *noiseGroup) stream(id int) stimulus.IPatternStream {
+	poi, _ := g.net.poiStreams.Get(id)
+	return poi.(stimulus.IPatternStream)
+}
+
+func (g *noiseGroup) Select(selector string) ([]int, error) {
+	count := g.net.poiStreams.Size()
+	if selector == "" {
+		selector = "all"
+	}
+
+	ids := []int{}
+	selected := map[int]bool{}
+
+	for _, term := range strings.Split(selector, ",") {
+		var terms []int
+		switch term {
+		case "all":
+			for id := 0; id < count; id++ {
+				terms = append(terms, id)
+			}
+		case "exc":
+			for id := 0; id < g.net.excitatory; id++ {
+				terms = append(terms, id)
+			}
+		case "inh":
+			for id := g.net.excitatory; id < count; id++ {
+				terms = append(terms, id)
+			}
+		default:
+			tagged, ok := g.net.tags[term]
+			if !ok {
+				var err error
+				if tagged, err = property.ParseIDs(term, count); err != nil {
+					return nil, fmt.Errorf("%v, expected IDs, exc, inh, all or a tag: %v", err, g.net.tagNames())
+				}
+			}
+			terms = tagged
+		}
+
+		for _, id := range terms {
+			if !selected[id] {
+				selected[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+func (g *noiseGroup) Get(id int) float64 {
+	return g.get(g.stream(id))
+}
+
+func (g *noiseGroup) Set(id int, value float64) {
+	g.set(g.stream(id), value)
+}
+
+func (g *noiseGroup) Label(id int) string {
+	label := "exc"
+	if id >= g.net.excitatory {
+		label = "inh"
+	}
+	for _, tag := range g.net.tagNames() {
+		for _, tagged := range g.net.tags[tag] {
+			if tagged == id {
+				label += ", " + tag
+			}
+		}
+	}
+	return label
+}
+
+func (s *Network) tagNames() []string {
+	names := []string{}
+	for tag := range s.tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Network) createPatterns(def *config.Definition) {
+	// ------------------------------------------------------------
+	// Create collection
+	s.pattern1 = stimulus.NewNPatternStream(s.createSchedule(def))
+
+	// Create patterns
+	streams := def.Pattern.Streams
+	if def.Pattern.Set != nil {
+		streams = def.Pattern.Set.Patterns[0].Streams
+	}
+	for id, bits := range streams {
+		spk := stimulus.NewSpikeStream().(*stimulus.SpikeStream)
+		spk.SetId(id)
+		spk.SetSpikes(config.Bits(bits))
+		s.pattern1.Add(spk)
+	}
+
+	if def.Pattern.Set != nil {
+		s.pattern1.SetPatternSet(s.createPatternSet(def.Pattern.Set))
+	}
+
+	perturb := def.Pattern.Perturb
+	s.pattern1.SetPerturbation(
+		stimulus.Perturbation{Jitter: perturb.Jitter, Deletion: perturb.Deletion, Insertion: perturb.Insertion},
+		s.seeds.Source("pattern/perturb"))
+
+	// fmt.Printf("createPatterns: \n%s\n", s.pattern1)
+}
+
+func (s *Network) createPatternSet(def *config.SetDef) *stimulus.PatternSet {
+	set := stimulus.NewPatternSet(def.Order, s.seeds.Source("pattern/order"))
+	for _, lp := range def.Patterns {
+		spikes := [][]byte{}
+		for _, bits := range lp.Streams {
+			spikes = append(spikes, config.Bits(bits))
+		}
+		set.Add(lp.Label, spikes)
+	}
+
+	// Validated with the definition.
+	set.SetScript(def.Script)
+
+	return set
+}
+
+func (s *Network) createSchedule(def *config.Definition) stimulus.ISchedule {
+	schedule := def.Pattern.Schedule
+	switch schedule.Type {
+	case config.ScheduleFixed:
+		return stimulus.NewFixedSchedule(schedule.Period)
+	case config.ScheduleList:
+		return stimulus.NewListSchedule(schedule.Times)
+	case config.ScheduleGamma:
+		return stimulus.NewGammaSchedule(s.seeds.Source("pattern"), schedule.Mean, schedule.Shape, schedule.Min)
+	}
+
+	poisson := def.Pattern.Poisson
+	ps := stimulus.NewPoissonSchedule(s.seeds.Source("pattern"), poisson.Max, poisson.Spread, poisson.Min)
+	if poisson.ISI != nil {
+		ps.SetDistribution(createDistribution(poisson.ISI))
+	}
+	return ps
+}
+
+// createNoise creates a noise stream, Poisson ISIs unless the
+// definition has a rate or a stream.
+func (s *Network) createNoise(def *config.Definition, id int) stimulus.IPatternStream {
+	src := s.seeds.Source(fmt.Sprintf("noise/%d", id))
+
+	var noise stimulus.IPatternStream
+	switch {
+	case def.Stream != nil:
+		noise = createStream(def.Stream, src, id)
+	case def.Rate != nil:
+		noise = stimulus.NewRateStream(src, createRate(def.Rate), def.Rate.Refractory)
+	default:
+		poi := stimulus.NewPoissonStream(src).(*stimulus.PoissonStream)
+		poi.Initialize(def.Poisson.Max, def.Poisson.Spread, def.Poisson.Min)
+		if def.Poisson.ISI != nil {
+			poi.SetDistribution(createDistribution(def.Poisson.ISI))
+		}
+		noise = poi
+	}
+
+	noise.SetId(id)
+	return noise
+}
+
+// createStream creates stream id of a regular, burst or oscillation
+// drive.
+func createStream(def *config.StreamDef, src *rng.Source, id int) stimulus.IPatternStream {
+	offset := def.Offset + id*def.Stagger
+
+	switch def.Type {
+	case config.StreamRegular:
+		return stimulus.NewRegularStream(def.Period, offset)
+	case config.StreamBurst:
+		return stimulus.NewBurstStream(def.Period, def.Spikes, def.Interval, offset)
+	}
+
+	rate := &stimulus.OscillationRate{
+		Mean:  def.Rate,
+		Theta: def.Theta, ThetaPhase: def.ThetaPhase, ThetaLocking: def.ThetaLocking,
+		Gamma: def.Gamma, GammaPhase: def.GammaPhase, GammaLocking: def.GammaLocking,
+	}
+	return stimulus.NewRateStream(src, rate, def.Refractory)
+}
+
+func createRate(def *config.RateDef) stimulus.IRate {
+	switch def.Type {
+	case config.RateSine:
+		return &stimulus.SineRate{Mean: def.Rate, Amplitude: def.Amplitude, Frequency: def.Frequency, Phase: def.Phase}
+	case config.RateStep:
+		return &stimulus.StepRate{From: def.Rate, To: def.To, At: def.Start}
+	case config.RateRamp:
+		return &stimulus.RampRate{From: def.Rate, To: def.To, Start: def.Start, End: def.End}
+	case config.RateSamples:
+		rate := new(stimulus.SampledRate)
+		for _, sample := range def.Samples {
+			rate.Times = append(rate.Times, sample[0])
+			rate.Rates = append(rate.Rates, sample[1])
+		}
+		return rate
+	}
+
+	return &stimulus.ConstantRate{Hz: def.Rate}
+}
+
+func createDistribution(def *config.ISIDef) dist.IDistribution {
+	switch def.Type {
+	case config.ISIGamma:
+		return dist.NewGammaISI(def.Mean, def.Shape)
+	case config.ISILogNormal:
+		return dist.NewLogNormalISI(def.Mean, def.CV)
+	case config.ISINormal:
+		return dist.NewNormalISI(def.Mean, def.SD)
+	case config.ISIUniform:
+		return dist.NewUniformISI(def.Mean, def.Width)
+	case config.ISIPoisson:
+		return dist.NewPoissonISI(def.Mean)
+	}
+
+	return dist.NewExponentialISI(def.Mean)
+}
@@ -0,0 +1,199 @@
+package pattern
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Load reads a pattern file, json for a .json extension and text
+// otherwise. The pattern is named after the file.
+func Load(path string) (*Pattern, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p *Pattern
+	if filepath.Ext(path) == ".json" {
+		p, err = ParseJSON(data)
+	} else {
+		p, err = ParseText(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return p, nil
+}
+
+// Save writes a pattern file, json for a .json extension and text
+// otherwise.
+func Save(path string, p *Pattern) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	data := p.Text()
+	if filepath.Ext(path) == ".json" {
+		var err error
+		if data, err = p.JSON(); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ParseText parses the text (and csv) form, one row per stream:
+//
+//	# comments and blank lines are ignored
+//	0000100001001001001000100
+//	0001001000001000100001000
+//
+// With a `length` row the streams are spike times instead, separated by
+// commas or spaces, where `-` is a stream without spikes:
+//
+//	length,25
+//	4,9,12,15,18,22
+//	-
+func ParseText(data []byte) (*Pattern, error) {
+	rows := [][]string{}
+	length := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' })
+		if fields[0] == "length" {
+			if len(fields) != 2 || len(rows) > 0 {
+				return nil, fmt.Errorf("line %d: expected `length,<ms>` before the streams", line)
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("line %d: length must be a number > 0, got `%s`", line, fields[1])
+			}
+			length = n
+			continue
+		}
+
+		rows = append(rows, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if length == 0 {
+		p := new(Pattern)
+		for i, row := range rows {
+			if len(row) != 1 {
+				return nil, fmt.Errorf("stream %d: expected a bit string, add a `length` row for spike times", i)
+			}
+			p.Streams = append(p.Streams, row[0])
+		}
+		if len(rows) > 0 {
+			p.Length = len(rows[0][0])
+		}
+		return p, p.Validate()
+	}
+
+	times := [][]int{}
+	for i, row := range rows {
+		spikes := []int{}
+		for _, field := range row {
+			if field == "-" {
+				continue
+			}
+			t, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("stream %d: spike time `%s` isn't a number", i, field)
+			}
+			spikes = append(spikes, t)
+		}
+		times = append(times, spikes)
+	}
+
+	p, err := FromTimes("", length, times)
+	if err != nil {
+		return nil, err
+	}
+	return p, p.Validate()
+}
+
+// Text returns the text form with bit strings, which ParseText reads
+// back.
+func (p *Pattern) Text() []byte {
+	var s strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&s, "# %s\n", p.Name)
+	}
+	for _, stream := range p.Streams {
+		s.WriteString(stream + "\n")
+	}
+	return []byte(s.String())
+}
+
+// jsonPattern is the json form, with either bits or times:
+//
+//	{"name": "a", "bits": ["0010...", ...]}
+//	{"name": "a", "length": 25, "times": [[4, 9, 12], [], ...]}
+type jsonPattern struct {
+	Name   string   `json:"name,omitempty"`
+	Length int      `json:"length,omitempty"`
+	Bits   []string `json:"bits,omitempty"`
+	Times  [][]int  `json:"times,omitempty"`
+}
+
+// ParseJSON parses the json form.
+func ParseJSON(data []byte) (*Pattern, error) {
+	jp := new(jsonPattern)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(jp); err != nil {
+		return nil, err
+	}
+
+	if len(jp.Bits) > 0 && len(jp.Times) > 0 {
+		return nil, fmt.Errorf("expected either bits or times, not both")
+	}
+
+	var p *Pattern
+	if len(jp.Times) > 0 {
+		var err error
+		if p, err = FromTimes(jp.Name, jp.Length, jp.Times); err != nil {
+			return nil, err
+		}
+	} else {
+		p = new(Pattern)
+		p.Name = jp.Name
+		p.Streams = jp.Bits
+		if len(jp.Bits) > 0 {
+			p.Length = len(jp.Bits[0])
+		}
+		if jp.Length != 0 && jp.Length != p.Length {
+			return nil, fmt.Errorf("length %d doesn't match the bits' length %d", jp.Length, p.Length)
+		}
+	}
+
+	return p, p.Validate()
+}
+
+// JSON returns the json form with bit strings.
+func (p *Pattern) JSON() ([]byte, error) {
+	return json.MarshalIndent(&jsonPattern{Name: p.Name, Length: p.Length, Bits: p.Streams}, "", "  ")
+}
@@ -0,0 +1,123 @@
+package pattern
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_FormatRoundTrip(t *testing.T) {
+	p, err := FromTimes("a", 12, [][]int{{0, 4, 11}, {}, {3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		file string
+		// json keeps the name, text files are named after the file.
+		name string
+	}{
+		{"a.json", "a"},
+		{"b.json", "a"},
+		{"a.txt", "a"},
+		{"c.csv", "c"},
+	}
+
+	dir := t.TempDir()
+	for _, c := range cases {
+		path := filepath.Join(dir, c.file)
+		if err := Save(path, p); err != nil {
+			t.Fatalf("%s: %v", c.file, err)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", c.file, err)
+		}
+
+		if got.Length != p.Length || !reflect.DeepEqual(got.Streams, p.Streams) {
+			t.Errorf("%s: loaded\n%v\nexpected\n%v", c.file, got, p)
+		}
+		if !reflect.DeepEqual(got.Times(), p.Times()) {
+			t.Errorf("%s: times %v, expected %v", c.file, got.Times(), p.Times())
+		}
+		if got.Name != c.name {
+			t.Errorf("%s: name `%s`, expected `%s`", c.file, got.Name, c.name)
+		}
+	}
+}
+
+func Test_ParseForms(t *testing.T) {
+	bits := []string{"100010000001", "000000000000", "000100000000"}
+
+	cases := []struct {
+		name string
+		json bool
+		data string
+		ok   bool
+	}{
+		{"bits", false, "# a\n100010000001\n\n000000000000\n000100000000\n", true},
+		{"times", false, "length,12\n0,4,11\n-\n3\n", true},
+		{"spaced times", false, "length 12\n0 4 11\n-\n3\n", true},
+		{"json bits", true, `{"name": "a", "bits": ["100010000001", "000000000000", "000100000000"]}`, true},
+		{"json times", true, `{"name": "a", "length": 12, "times": [[0, 4, 11], [], [3]]}`, true},
+
+		{"ragged bits", false, "1000\n100\n", false},
+		{"bad bit", false, "1020\n", false},
+		{"late length", false, "1000\nlength,4\n", false},
+		{"time outside", false, "length,12\n12\n", false},
+		{"time not a number", false, "length,12\n4,x\n", false},
+		{"times without length", false, "0,4\n", false},
+		{"json both", true, `{"bits": ["10"], "times": [[0]], "length": 2}`, false},
+		{"json length", true, `{"bits": ["10"], "length": 3}`, false},
+		{"json unknown field", true, `{"bitz": ["10"]}`, false},
+		{"empty", false, "# nothing\n", false},
+	}
+
+	for _, c := range cases {
+		var p *Pattern
+		var err error
+		if c.json {
+			p, err = ParseJSON([]byte(c.data))
+		} else {
+			p, err = ParseText([]byte(c.data))
+		}
+
+		if (err == nil) != c.ok {
+			t.Errorf("%s: error %v, expected success %v", c.name, err, c.ok)
+			continue
+		}
+		if c.ok && !reflect.DeepEqual(p.Streams, bits) {
+			t.Errorf("%s: streams %v, expected %v", c.name, p.Streams, bits)
+		}
+	}
+}
+
+func Test_DefinitionAndLibraryRoundTrip(t *testing.T) {
+	// Definitions hold their streams reversed.
+	streams := []string{"0000100001", "1000000011"}
+	p := FromDefinition("d", streams)
+	if p.Streams[0] != "1000010000" {
+		t.Errorf("stream 0 is %s, expected it reversed", p.Streams[0])
+	}
+	if !reflect.DeepEqual(p.Definition(), streams) {
+		t.Errorf("definition %v, expected %v", p.Definition(), streams)
+	}
+
+	l := NewLibrary(t.TempDir())
+	if err := l.Save(p); err != nil {
+		t.Fatal(err)
+	}
+	if names := l.Names(); !reflect.DeepEqual(names, []string{"d"}) {
+		t.Errorf("names %v, expected [d]", names)
+	}
+	got, err := l.Find("d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Definition(), streams) {
+		t.Errorf("found %v, expected %v", got.Definition(), streams)
+	}
+	if _, err = l.Find("e"); err == nil {
+		t.Errorf("expected no pattern `e`")
+	}
+}
@@ -0,0 +1,229 @@
+package pattern
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// Spec describes a family of generated patterns, for example:
+//
+//	{"streams": 10, "length": 25, "rate": 120, "minISI": 3,
+//	 "seed": 7, "count": 4, "overlap": 0.25}
+type Spec struct {
+	Streams int `json:"streams"`
+	// Length (ms) of every stream.
+	Length int `json:"length"`
+
+	// Spikes per stream. Without it Rate (Hz) sets the count.
+	Spikes int     `json:"spikes,omitempty"`
+	Rate   float64 `json:"rate,omitempty"`
+
+	// The minimum interval (ms) between a stream's spikes.
+	MinISI int `json:"minISI,omitempty"`
+
+	Seed int64 `json:"seed"`
+
+	// How many patterns the family has, default 1.
+	Count int `json:"count,omitempty"`
+	// The fraction of a stream's spikes every pattern of the family
+	// shares. The remaining spikes are never shared.
+	Overlap float64 `json:"overlap,omitempty"`
+}
+
+// LoadSpec reads and validates a generator spec.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := new(Spec)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if err = spec.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return spec, nil
+}
+
+// Validate checks the spec's values and that the spikes fit into the
+// length with the minimum interval.
+func (s *Spec) Validate() error {
+	if s.Streams <= 0 {
+		return fmt.Errorf("streams must be > 0, got %d", s.Streams)
+	}
+	if s.Length <= 0 {
+		return fmt.Errorf("length must be > 0, got %d", s.Length)
+	}
+	if (s.Spikes > 0) == (s.Rate > 0) {
+		return fmt.Errorf("expected either spikes or rate > 0")
+	}
+	if s.Spikes < 0 || s.Rate < 0 || s.MinISI < 0 || s.Count < 0 {
+		return fmt.Errorf("spikes, rate, minISI and count can't be negative")
+	}
+	if s.Overlap < 0 || s.Overlap > 1 {
+		return fmt.Errorf("overlap must be within [0, 1], got %g", s.Overlap)
+	}
+
+	if s.spikes() == 0 {
+		return fmt.Errorf("a rate of %gHz gives no spikes in %dms", s.Rate, s.Length)
+	}
+	if most := s.mostSpikes(); s.spikes() > most {
+		return fmt.Errorf("%d spikes don't fit into %dms with a minimum interval of %dms, at most %d do",
+			s.spikes(), s.Length, s.MinISI, most)
+	}
+
+	return nil
+}
+
+// spikes is the spike count per stream.
+func (s *Spec) spikes() int {
+	if s.Spikes > 0 {
+		return s.Spikes
+	}
+	return int(math.Round(s.Rate * float64(s.Length) / 1000.0))
+}
+
+func (s *Spec) mostSpikes() int {
+	if s.MinISI <= 1 {
+		return s.Length
+	}
+	return (s.Length-1)/s.MinISI + 1
+}
+
+// Generate creates the spec's family of patterns. A single pattern is
+// named name, a family name-1, name-2...
+//
+// Every stream of every pattern has the same spike count. Each stream
+// has a core of shared spikes, the rest of its spikes are at times no
+// other pattern uses, so every pair of patterns shares exactly the
+// core.
+func Generate(name string, spec *Spec) ([]*Pattern, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	count := spec.Count
+	if count == 0 {
+		count = 1
+	}
+
+	ran, _ := rng.New(spec.Seed)
+
+	spikes := spec.spikes()
+	shared := int(math.Round(spec.Overlap * float64(spikes)))
+
+	family := make([]*Pattern, count)
+	for i := range family {
+		family[i] = new(Pattern)
+		family[i].Name = name
+		if count > 1 {
+			family[i].Name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		family[i].Length = spec.Length
+	}
+
+	for stream := 0; stream < spec.Streams; stream++ {
+		// Times any pattern has used so far.
+		used := map[int]bool{}
+
+		var core []int
+		for i, p := range family {
+			times, err := place(ran, spec, append([]int{}, core...), spikes-len(core), used)
+			if err != nil {
+				return nil, fmt.Errorf("stream %d of pattern %d: %v", stream, i+1, err)
+			}
+
+			if i == 0 {
+				// The core is a random choice of the first
+				// pattern's spikes.
+				perm := ran.Perm(len(times))
+				for _, j := range perm[:shared] {
+					core = append(core, times[j])
+				}
+			}
+			for _, t := range times {
+				used[t] = true
+			}
+
+			p.Streams = append(p.Streams, bits(spec.Length, times))
+		}
+	}
+
+	return family, nil
+}
+
+// place adds n spikes to times at random, keeping the minimum interval
+// and avoiding the used times.
+func place(ran *rand.Rand, spec *Spec, times []int, n int, used map[int]bool) ([]int, error) {
+	for ; n > 0; n-- {
+		candidates := []int{}
+		for t := 0; t < spec.Length; t++ {
+			if !used[t] && apart(t, times, spec.MinISI) {
+				candidates = append(candidates, t)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no room for another spike, try fewer spikes, a shorter minISI, fewer patterns or more overlap")
+		}
+		times = append(times, candidates[ran.Intn(len(candidates))])
+	}
+
+	sort.Ints(times)
+	return times, nil
+}
+
+func apart(t int, times []int, minISI int) bool {
+	for _, s := range times {
+		d := t - s
+		if d < 0 {
+			d = -d
+		}
+		if d == 0 || d < minISI {
+			return false
+		}
+	}
+	return true
+}
+
+func bits(length int, times []int) string {
+	b := bytes.Repeat([]byte{'0'}, length)
+	for _, t := range times {
+		b[t] = '1'
+	}
+	return string(b)
+}
+
+// Overlap is the fraction of a's spikes that b has at the same time in
+// the same stream.
+func Overlap(a, b *Pattern) float64 {
+	spikes, shared := 0, 0
+	for i, stream := range a.Streams {
+		for t, c := range stream {
+			if c != '1' {
+				continue
+			}
+			spikes++
+			if i < len(b.Streams) && t < len(b.Streams[i]) && b.Streams[i][t] == '1' {
+				shared++
+			}
+		}
+	}
+
+	if spikes == 0 {
+		return 0
+	}
+	return float64(shared) / float64(spikes)
+}
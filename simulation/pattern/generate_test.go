@@ -0,0 +1,96 @@
+package pattern
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_GenerateDensityAndMinISI(t *testing.T) {
+	cases := []struct {
+		name   string
+		spec   Spec
+		spikes int
+	}{
+		{"spikes", Spec{Streams: 10, Length: 25, Spikes: 4, MinISI: 3, Seed: 7}, 4},
+		{"rate", Spec{Streams: 8, Length: 50, Rate: 120, MinISI: 2, Seed: 1}, 6},
+		{"dense", Spec{Streams: 4, Length: 40, Spikes: 5, MinISI: 6, Seed: 3}, 5},
+		{"family", Spec{Streams: 10, Length: 40, Spikes: 6, MinISI: 2, Seed: 9, Count: 3, Overlap: 0.5}, 6},
+	}
+
+	for _, c := range cases {
+		family, err := Generate("a", &c.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		for _, p := range family {
+			if len(p.Streams) != c.spec.Streams {
+				t.Fatalf("%s: %s has %d streams, expected %d", c.name, p.Name, len(p.Streams), c.spec.Streams)
+			}
+			for i, times := range p.Times() {
+				if len(times) != c.spikes {
+					t.Fatalf("%s: %s stream %d has %d spikes, expected %d", c.name, p.Name, i, len(times), c.spikes)
+				}
+				for j := 1; j < len(times); j++ {
+					if times[j]-times[j-1] < c.spec.MinISI {
+						t.Fatalf("%s: %s stream %d ISI %d is below the minimum %d", c.name, p.Name, i, times[j]-times[j-1], c.spec.MinISI)
+					}
+				}
+			}
+		}
+
+		// The same seed generates the same family.
+		again, _ := Generate("a", &c.spec)
+		if !reflect.DeepEqual(family, again) {
+			t.Fatalf("%s: a second run with seed %d differs", c.name, c.spec.Seed)
+		}
+	}
+}
+
+func Test_GenerateOverlap(t *testing.T) {
+	spec := Spec{Streams: 10, Length: 40, Spikes: 6, MinISI: 2, Seed: 9, Count: 4, Overlap: 0.5}
+	family, err := Generate("a", &spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range family {
+		for j := range family {
+			expected := spec.Overlap
+			if i == j {
+				expected = 1
+			}
+			if o := Overlap(family[i], family[j]); math.Abs(o-expected) > 1e-9 {
+				t.Fatalf("%s and %s overlap %g, expected %g", family[i].Name, family[j].Name, o, expected)
+			}
+		}
+	}
+}
+
+func Test_SpecValidateLimits(t *testing.T) {
+	cases := []struct {
+		name string
+		spec Spec
+		err  string
+	}{
+		{"too dense for the minimum ISI", Spec{Streams: 1, Length: 25, Spikes: 6, MinISI: 5}, "don't fit"},
+		{"densest fit", Spec{Streams: 1, Length: 25, Spikes: 5, MinISI: 6}, ""},
+		{"every ms", Spec{Streams: 1, Length: 10, Spikes: 10}, ""},
+		{"more spikes than ms", Spec{Streams: 1, Length: 10, Spikes: 11}, "don't fit"},
+		{"rate too low", Spec{Streams: 1, Length: 10, Rate: 20}, "no spikes"},
+		{"spikes and rate", Spec{Streams: 1, Length: 10, Spikes: 2, Rate: 20}, "either spikes or rate"},
+		{"overlap", Spec{Streams: 1, Length: 10, Spikes: 2, Overlap: 1.5}, "overlap"},
+	}
+
+	for _, c := range cases {
+		err := c.spec.Validate()
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+			t.Errorf("%s: got %v, expected an error with `%s`", c.name, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package pattern
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LibraryDir is the pattern library's directory, relative to the
+// working directory.
+const LibraryDir = "patterns"
+
+// Extensions of pattern files, in the order a name is looked up.
+var extensions = []string{".json", ".csv", ".txt"}
+
+// Library is a directory of pattern files that are referenced by name,
+// the file name without its extension.
+type Library struct {
+	// Paths are relative to the working directory.
+	workingPath string
+	dir         string
+}
+
+// NewLibrary creates the library of a working directory. The library
+// needn't exist yet.
+func NewLibrary(workingPath string) *Library {
+	l := new(Library)
+	l.workingPath = workingPath
+	l.dir = filepath.Join(workingPath, LibraryDir)
+	return l
+}
+
+// Names returns the names of the library's patterns, sorted.
+func (l *Library) Names() []string {
+	names := []string{}
+
+	files, err := ioutil.ReadDir(l.dir)
+	if err != nil {
+		return names
+	}
+
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		for _, e := range extensions {
+			if ext == e && !file.IsDir() {
+				names = append(names, strings.TrimSuffix(file.Name(), ext))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Find loads a pattern by name, or from a file relative to the working
+// directory if name has an extension.
+func (l *Library) Find(name string) (*Pattern, error) {
+	if filepath.Ext(name) != "" {
+		return Load(filepath.Join(l.workingPath, name))
+	}
+
+	for _, ext := range extensions {
+		path := filepath.Join(l.dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+
+	return nil, fmt.Errorf("no pattern `%s` in %s, available: %s", name, l.dir, strings.Join(l.Names(), ", "))
+}
+
+// Save writes a pattern into the library as json, replacing any
+// pattern of the same name.
+func (l *Library) Save(p *Pattern) error {
+	if p.Name == "" {
+		return fmt.Errorf("a pattern needs a name to be saved")
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	return Save(filepath.Join(l.dir, p.Name+".json"), p)
+}
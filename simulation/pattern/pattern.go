@@ -0,0 +1,113 @@
+package pattern
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern is a spike pattern, one stream per synapse. Every stream has
+// the same length (ms).
+//
+// Streams are bit strings in time order: the first bit is the first ms
+// of a presentation, as SpikeStream.String() prints it. Definitions
+// hold their streams in SetSpikes order, which is reversed, see
+// FromDefinition and Definition.
+type Pattern struct {
+	Name    string
+	Length  int
+	Streams []string
+}
+
+// FromDefinition creates a pattern from a definition's streams.
+func FromDefinition(name string, streams []string) *Pattern {
+	p := new(Pattern)
+	p.Name = name
+	for _, stream := range streams {
+		p.Streams = append(p.Streams, reverse(stream))
+	}
+	if len(streams) > 0 {
+		p.Length = len(streams[0])
+	}
+	return p
+}
+
+// FromTimes creates a pattern from each stream's spike times, in ms
+// from the start of a presentation.
+func FromTimes(name string, length int, times [][]int) (*Pattern, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("length must be > 0, got %d", length)
+	}
+
+	p := new(Pattern)
+	p.Name = name
+	p.Length = length
+
+	for i, spikes := range times {
+		for _, t := range spikes {
+			if t < 0 || t >= length {
+				return nil, fmt.Errorf("stream %d: spike time %d is outside 0-%d", i, t, length-1)
+			}
+		}
+		p.Streams = append(p.Streams, bits(length, spikes))
+	}
+
+	return p, nil
+}
+
+// Definition returns the streams in the order a definition holds them.
+func (p *Pattern) Definition() []string {
+	streams := []string{}
+	for _, stream := range p.Streams {
+		streams = append(streams, reverse(stream))
+	}
+	return streams
+}
+
+// Times returns each stream's spike times.
+func (p *Pattern) Times() [][]int {
+	times := [][]int{}
+	for _, stream := range p.Streams {
+		spikes := []int{}
+		for t, c := range stream {
+			if c == '1' {
+				spikes = append(spikes, t)
+			}
+		}
+		times = append(times, spikes)
+	}
+	return times
+}
+
+// Validate checks that there is at least one stream, that every stream
+// is Length bits long and has only 0s and 1s.
+func (p *Pattern) Validate() error {
+	if len(p.Streams) == 0 {
+		return fmt.Errorf("pattern `%s` has no streams", p.Name)
+	}
+	if p.Length <= 0 {
+		return fmt.Errorf("pattern `%s` length must be > 0, got %d", p.Name, p.Length)
+	}
+
+	for i, stream := range p.Streams {
+		if len(stream) != p.Length {
+			return fmt.Errorf("stream %d: length %d doesn't match the pattern's length %d", i, len(stream), p.Length)
+		}
+		if idx := strings.IndexFunc(stream, func(c rune) bool { return c != '0' && c != '1' }); idx >= 0 {
+			return fmt.Errorf("stream %d: invalid character `%c` at position %d, only 0 or 1 allowed", i, stream[idx], idx)
+		}
+	}
+
+	return nil
+}
+
+func (p Pattern) String() string {
+	return strings.Join(p.Streams, "\n")
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,55 @@
+package property
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IGroup gives a property a value per element, for example one per
+// noise stream. Elements are addressed by appending `@selector` to the
+// property's name, e.g. "Poisson Max@0-3". Without a selector every
+// element is addressed.
+type IGroup interface {
+	// Select returns the elements a selector addresses, all of them
+	// for an empty selector.
+	Select(selector string) ([]int, error)
+
+	Get(element int) float64
+	Set(element int, value float64)
+
+	// Label describes an element for listings, for example "exc".
+	Label(element int) string
+}
+
+// ParseIDs parses comma separated IDs and inclusive ranges, for
+// example "0-3,7", where every ID is less than count.
+func ParseIDs(text string, count int) ([]int, error) {
+	ids := []int{}
+
+	for _, term := range strings.Split(text, ",") {
+		lo, hi := term, term
+		if i := strings.Index(term, "-"); i > 0 {
+			lo, hi = term[:i], term[i+1:]
+		}
+
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("`%s` isn't an ID or range of IDs", term)
+		}
+		to, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, fmt.Errorf("`%s` isn't an ID or range of IDs", term)
+		}
+
+		if from < 0 || to >= count || from > to {
+			return nil, fmt.Errorf("`%s` is outside of 0-%d", term, count-1)
+		}
+
+		for id := from; id <= to; id++ {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,175 @@
+package property
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Actions recorded in a History.
+const (
+	ActionSet   = "set"
+	ActionNudge = "nudge"
+	ActionUndo  = "undo"
+	ActionRedo  = "redo"
+)
+
+// Change is a single entry in a History.
+type Change struct {
+	Seq  int
+	When time.Time
+	// Sim time (ms) when the change was made.
+	SimTime float64
+
+	// The name as given, including any selector.
+	Property string
+	Action   string
+	// Who asked for it, for example "key", "console" or "optimizer".
+	Source string
+
+	// Values of the addressed elements before and after.
+	Old []float64
+	New []float64
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("#%d %s t=%gms %s %s %s -> %s (%s)",
+		c.Seq, c.When.Format("15:04:05.000"), c.SimTime, c.Action, c.Property,
+		summarize(c.Old), summarize(c.New), c.Source)
+}
+
+// summarize shows equal values once and differing values as a range.
+func summarize(values []float64) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if lo == hi {
+		return fmt.Sprintf("%g", lo)
+	}
+	return fmt.Sprintf("%g..%g", lo, hi)
+}
+
+// History makes changes to a Registry's properties, logging every one
+// of them, and can undo and redo them. Undo and redo are logged too.
+type History struct {
+	registry *Registry
+
+	log  []*Change
+	undo []*Change
+	redo []*Change
+}
+
+// NewHistory creates an empty history for a registry.
+func NewHistory(registry *Registry) *History {
+	h := new(History)
+	h.registry = registry
+	return h
+}
+
+// Set sets a property, see Registry.Set.
+func (h *History) Set(name string, value float64, source string, simTime float64) (*Change, error) {
+	return h.apply(name, ActionSet, source, simTime, func() error {
+		return h.registry.Set(name, value)
+	})
+}
+
+// Nudge moves a property, see Registry.Nudge.
+func (h *History) Nudge(name string, delta float64, source string, simTime float64) (*Change, error) {
+	return h.apply(name, ActionNudge, source, simTime, func() error {
+		_, err := h.registry.Nudge(name, delta)
+		return err
+	})
+}
+
+func (h *History) apply(name, action, source string, simTime float64, change func() error) (*Change, error) {
+	old, err := h.registry.Values(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = change(); err != nil {
+		return nil, err
+	}
+
+	values, _ := h.registry.Values(name)
+	c := h.record(name, action, source, simTime, old, values)
+
+	h.undo = append(h.undo, c)
+	h.redo = h.redo[:0]
+
+	return c, nil
+}
+
+// Undo reverts the most recent change that hasn't been undone.
+func (h *History) Undo(source string, simTime float64) (*Change, error) {
+	if len(h.undo) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	c := h.undo[len(h.undo)-1]
+	if err := h.registry.SetValues(c.Property, c.Old); err != nil {
+		return nil, err
+	}
+
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, c)
+
+	return h.record(c.Property, ActionUndo, source, simTime, c.New, c.Old), nil
+}
+
+// Redo re-applies the most recently undone change.
+func (h *History) Redo(source string, simTime float64) (*Change, error) {
+	if len(h.redo) == 0 {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	c := h.redo[len(h.redo)-1]
+	if err := h.registry.SetValues(c.Property, c.New); err != nil {
+		return nil, err
+	}
+
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, c)
+
+	return h.record(c.Property, ActionRedo, source, simTime, c.Old, c.New), nil
+}
+
+func (h *History) record(name, action, source string, simTime float64, old, values []float64) *Change {
+	c := &Change{
+		Seq:      len(h.log),
+		When:     time.Now(),
+		SimTime:  simTime,
+		Property: name,
+		Action:   action,
+		Source:   source,
+		Old:      old,
+		New:      values,
+	}
+	h.log = append(h.log, c)
+	return c
+}
+
+// Changes returns every logged change, oldest first.
+func (h *History) Changes() []*Change {
+	return h.log
+}
+
+// List describes the most recent n changes (all if n <= 0), one per line.
+func (h *History) List(n int) string {
+	changes := h.log
+	if n > 0 && len(changes) > n {
+		changes = changes[len(changes)-n:]
+	}
+
+	lines := []string{}
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,96 @@
+package property
+
+import "testing"
+
+func Test_HistoryUndoRedo(t *testing.T) {
+	type step struct {
+		action string
+		value  float64
+		ok     bool
+		// The property's value afterwards.
+		want float64
+	}
+
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{"nothing to undo or redo", []step{
+			{ActionUndo, 0, false, 1},
+			{ActionRedo, 0, false, 1},
+		}},
+		{"undo and redo", []step{
+			{ActionSet, 5, true, 5},
+			{ActionNudge, 2, true, 7},
+			{ActionUndo, 0, true, 5},
+			{ActionUndo, 0, true, 1},
+			{ActionUndo, 0, false, 1},
+			{ActionRedo, 0, true, 5},
+			{ActionRedo, 0, true, 7},
+			{ActionRedo, 0, false, 7},
+		}},
+		{"an edit clears redo", []step{
+			{ActionSet, 5, true, 5},
+			{ActionSet, 6, true, 6},
+			{ActionUndo, 0, true, 5},
+			{ActionNudge, -1, true, 4},
+			{ActionRedo, 0, false, 4},
+			{ActionUndo, 0, true, 5},
+			{ActionUndo, 0, true, 1},
+		}},
+		{"rejected edits aren't recorded", []step{
+			{ActionSet, 5, true, 5},
+			{ActionSet, 11, false, 5},
+			{ActionUndo, 0, true, 1},
+			{ActionUndo, 0, false, 1},
+		}},
+		{"a nudge stops at the range", []step{
+			{ActionNudge, 20, true, 10},
+			{ActionUndo, 0, true, 1},
+			{ActionRedo, 0, true, 10},
+		}},
+	}
+
+	for _, c := range cases {
+		value := 1.0
+		r := NewRegistry()
+		r.Register(&Property{
+			Name: "Gain", Type: Float, Min: 0, Max: 10, Default: 1, Step: 1,
+			Get: func() float64 { return value },
+			Set: func(v float64) { value = v },
+		})
+		h := NewHistory(r)
+
+		for i, s := range c.steps {
+			var err error
+			switch s.action {
+			case ActionSet:
+				_, err = h.Set("Gain", s.value, "test", 0)
+			case ActionNudge:
+				_, err = h.Nudge("Gain", s.value, "test", 0)
+			case ActionUndo:
+				_, err = h.Undo("test", 0)
+			case ActionRedo:
+				_, err = h.Redo("test", 0)
+			}
+
+			if (err == nil) != s.ok {
+				t.Errorf("%s: step %d %s: error %v, expected success %v", c.name, i, s.action, err, s.ok)
+			}
+			if value != s.want {
+				t.Errorf("%s: step %d %s: value %g, expected %g", c.name, i, s.action, value, s.want)
+			}
+		}
+
+		// Every successful step is logged.
+		logged := 0
+		for _, s := range c.steps {
+			if s.ok {
+				logged++
+			}
+		}
+		if n := len(h.Changes()); n != logged {
+			t.Errorf("%s: %d changes logged, expected %d", c.name, n, logged)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package property
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Preset is a set of property values by name. Groups whose elements
+// differ are stored per element, e.g. "Poisson Max@3".
+type Preset map[string]float64
+
+// Names returns the preset's property names in the order they should
+// be applied: whole properties before any of their selections.
+func (p Preset) Names() []string {
+	names := []string{}
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := strings.Contains(names[i], "@"), strings.Contains(names[j], "@")
+		if si != sj {
+			return sj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Snapshot captures the value of every property.
+func (r *Registry) Snapshot() Preset {
+	preset := Preset{}
+
+	for _, name := range r.Names() {
+		p := r.properties[name]
+		if p.Group == nil {
+			preset[name] = p.Get()
+			continue
+		}
+
+		elements, _ := p.Group.Select("")
+		same := true
+		for _, e := range elements {
+			same = same && p.Group.Get(e) == p.Group.Get(elements[0])
+		}
+
+		if same {
+			preset[name] = p.Group.Get(elements[0])
+			continue
+		}
+		for _, e := range elements {
+			preset[fmt.Sprintf("%s@%d", name, e)] = p.Group.Get(e)
+		}
+	}
+
+	return preset
+}
+
+// Diff describes how the preset's values differ from the current
+// values, one line per property, e.g. "Poisson Max: 300 -> 250".
+// Names the registry doesn't have are ignored.
+func (r *Registry) Diff(preset Preset) []string {
+	lines := []string{}
+
+	for _, name := range preset.Names() {
+		p, err := r.Find(name)
+		if err != nil {
+			continue
+		}
+
+		values, _ := r.Values(name)
+		differs := false
+		for _, v := range values {
+			differs = differs || v != preset[name]
+		}
+
+		if differs {
+			current, _ := r.Value(name)
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", name, current, p.Format(preset[name])))
+		}
+	}
+
+	return lines
+}
+
+// Presets are named presets kept in a json file.
+type Presets struct {
+	path    string
+	Presets map[string]Preset `json:"presets"`
+}
+
+// LoadPresets reads a presets file, a missing file has no presets.
+func LoadPresets(path string) (*Presets, error) {
+	ps := new(Presets)
+	ps.path = path
+	ps.Presets = map[string]Preset{}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, ps); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if ps.Presets == nil {
+		ps.Presets = map[string]Preset{}
+	}
+
+	return ps, nil
+}
+
+// Save writes the presets back to their file.
+func (ps *Presets) Save() error {
+	data, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ps.path, data, 0644)
+}
+
+// Names returns the preset names, sorted.
+func (ps *Presets) Names() []string {
+	names := []string{}
+	for name := range ps.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set adds or replaces a preset. Names of only digits are refused as
+// they are positions, see Find.
+func (ps *Presets) Set(name string, preset Preset) error {
+	if isPosition(name) {
+		return fmt.Errorf("a preset can't be named `%s`, numbers are positions in the list", name)
+	}
+	ps.Presets[name] = preset
+	return nil
+}
+
+// Find returns a preset by name or, for a number, by its 1 based
+// position in Names.
+func (ps *Presets) Find(name string) (string, Preset, error) {
+	names := ps.Names()
+
+	if isPosition(name) {
+		if i, err := strconv.Atoi(name); err == nil && i >= 1 && i <= len(names) {
+			return names[i-1], ps.Presets[names[i-1]], nil
+		}
+		return "", nil, fmt.Errorf("no preset %s, there are %d", name, len(names))
+	}
+
+	if preset, ok := ps.Presets[name]; ok {
+		return name, preset, nil
+	}
+
+	return "", nil, fmt.Errorf("no preset `%s`, available: %s", name, strings.Join(names, ", "))
+}
+
+func isPosition(name string) bool {
+	return name != "" && strings.IndexFunc(name, func(c rune) bool { return c < '0' || c > '9' }) < 0
+}
@@ -0,0 +1,49 @@
+package property
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func Test_PresetsFind(t *testing.T) {
+	ps, err := LoadPresets(filepath.Join(t.TempDir(), "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorted: alpha, slot1, slot3
+	for _, name := range []string{"slot3", "alpha", "slot1"} {
+		if err = ps.Set(name, Preset{"Gain": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		found string
+	}{
+		{"slot3", "slot3"},
+		{"alpha", "alpha"},
+		{"1", "alpha"},
+		{"3", "slot3"},
+		{"4", ""},
+		{"0", ""},
+		{"-1", ""},
+		{"beta", ""},
+	}
+
+	for _, c := range cases {
+		found, _, err := ps.Find(c.name)
+		if (err == nil) != (c.found != "") || found != c.found {
+			t.Errorf("find %s: found `%s` (%v), expected `%s`", c.name, found, err, c.found)
+		}
+	}
+
+	for _, name := range []string{"2", "007"} {
+		if err = ps.Set(name, Preset{}); err == nil {
+			t.Errorf("expected a preset named %s to be refused", name)
+		}
+	}
+	if err = ps.Set("2b", Preset{}); err != nil {
+		t.Errorf("expected 2b to be a name: %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package property
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value types
+const (
+	Float = "float"
+	Int   = "int"
+)
+
+// Property is a tunable parameter addressed by name, for example
+// "Poisson Max". Components register their properties so the console,
+// key maps, definitions and sweeps can find them without knowing the
+// component.
+type Property struct {
+	Name string
+	// Float or Int
+	Type  string
+	Units string
+
+	// Allowed range, inclusive.
+	Min float64
+	Max float64
+
+	Default float64
+	// How far a single up/down nudge moves the value.
+	Step float64
+
+	Get func() float64
+	Set func(value float64)
+
+	// Set for properties with a value per element, Get and Set are
+	// then unused.
+	Group IGroup
+}
+
+// Check reports if value is allowed.
+func (p *Property) Check(value float64) error {
+	if math.IsNaN(value) || value < p.Min || value > p.Max {
+		return fmt.Errorf("%s %g is outside [%g, %g]", p.Name, value, p.Min, p.Max)
+	}
+	if p.Type == Int && value != math.Trunc(value) {
+		return fmt.Errorf("%s must be a whole number, got %g", p.Name, value)
+	}
+	return nil
+}
+
+// Clamp limits value to the property's range.
+func (p *Property) Clamp(value float64) float64 {
+	value = math.Max(p.Min, math.Min(p.Max, value))
+	if p.Type == Int {
+		value = math.Round(value)
+	}
+	return value
+}
+
+// Format formats value for display.
+func (p *Property) Format(value float64) string {
+	if p.Type == Int {
+		return strconv.Itoa(int(value))
+	}
+	return fmt.Sprintf("%0.4f", value)
+}
+
+// Parse converts text to a value of the property's type.
+func (p *Property) Parse(text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s value `%s` isn't a number", p.Name, text)
+	}
+	return value, nil
+}
+
+// Registry holds properties by name. A Registry isn't safe for
+// concurrent use, the owner serializes access.
+type Registry struct {
+	properties map[string]*Property
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.properties = map[string]*Property{}
+	return r
+}
+
+// Register adds a property. Type defaults to Float and Step to 1.
+func (r *Registry) Register(p *Property) {
+	if _, dup := r.properties[p.Name]; dup {
+		panic(fmt.Sprintf("Property: `%s` registered twice", p.Name))
+	}
+	if p.Type == "" {
+		p.Type = Float
+	}
+	if p.Step == 0 {
+		p.Step = 1
+	}
+	r.properties[p.Name] = p
+}
+
+// Has reports if a property is registered, name may have a selector.
+func (r *Registry) Has(name string) bool {
+	_, _, err := r.Resolve(name)
+	return err == nil
+}
+
+// Find returns the named property, name may have a selector.
+func (r *Registry) Find(name string) (*Property, error) {
+	p, _, err := r.Resolve(name)
+	return p, err
+}
+
+// Resolve splits a name into its property and, for groups, the
+// addressed elements, e.g. "Poisson Max@exc". Without a selector a
+// group addresses every element.
+func (r *Registry) Resolve(name string) (*Property, []int, error) {
+	base, selector := name, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		base, selector = name[:i], name[i+1:]
+	}
+
+	p, ok := r.properties[base]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown property `%s`, available: %s", base, strings.Join(r.Names(), ", "))
+	}
+
+	if p.Group == nil {
+		if selector != "" {
+			return nil, nil, fmt.Errorf("%s has a single value, it can't select `%s`", base, selector)
+		}
+		return p, nil, nil
+	}
+
+	elements, err := p.Group.Select(selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if len(elements) == 0 {
+		return nil, nil, fmt.Errorf("%s doesn't select anything", name)
+	}
+
+	return p, elements, nil
+}
+
+// Names returns the registered names, sorted.
+func (r *Registry) Names() []string {
+	names := []string{}
+	for name := range r.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set checks and sets a property's value, for groups the value of
+// every addressed element.
+func (r *Registry) Set(name string, value float64) error {
+	p, elements, err := r.Resolve(name)
+	if err != nil {
+		return err
+	}
+	if err = p.Check(value); err != nil {
+		return err
+	}
+
+	if p.Group == nil {
+		p.Set(value)
+		return nil
+	}
+
+	for _, e := range elements {
+		p.Group.Set(e, value)
+	}
+	return nil
+}
+
+// Nudge moves a property by delta, stopping at the range's ends, and
+// returns the new value. Group elements move individually so they keep
+// their differences.
+func (r *Registry) Nudge(name string, delta float64) (float64, error) {
+	p, elements, err := r.Resolve(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.Group == nil {
+		value := p.Clamp(p.Get() + delta)
+		p.Set(value)
+		return value, nil
+	}
+
+	value := 0.0
+	for _, e := range elements {
+		value = p.Clamp(p.Group.Get(e) + delta)
+		p.Group.Set(e, value)
+	}
+	return value, nil
+}
+
+// Values returns the values of a group's addressed elements, or a
+// single property's value.
+func (r *Registry) Values(name string) ([]float64, error) {
+	p, elements, err := r.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Group == nil {
+		return []float64{p.Get()}, nil
+	}
+
+	values := make([]float64, len(elements))
+	for i, e := range elements {
+		values[i] = p.Group.Get(e)
+	}
+	return values, nil
+}
+
+// SetValues restores values returned by Values for the same name.
+func (r *Registry) SetValues(name string, values []float64) error {
+	p, elements, err := r.Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	if p.Group == nil {
+		elements = []int{0}
+	}
+	if len(values) != len(elements) {
+		return fmt.Errorf("%s has %d values, expected %d", name, len(values), len(elements))
+	}
+
+	if p.Group == nil {
+		p.Set(values[0])
+		return nil
+	}
+
+	for i, e := range elements {
+		p.Group.Set(e, values[i])
+	}
+	return nil
+}
+
+// Value formats a property's value for display. Differing group
+// elements are shown as the range of their values, e.g. 50..300
+func (r *Registry) Value(name string) (string, error) {
+	p, err := r.Find(name)
+	if err != nil {
+		return "", err
+	}
+	values, _ := r.Values(name)
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	if lo == hi {
+		return p.Format(lo), nil
+	}
+	return p.Format(lo) + ".." + p.Format(hi), nil
+}
+
+// Elements lists the value of every addressed group element, one per
+// line.
+func (r *Registry) Elements(name string) (string, error) {
+	p, elements, err := r.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Group == nil {
+		return r.describe(p), nil
+	}
+
+	var s strings.Builder
+	for i, e := range elements {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		fmt.Fprintf(&s, "%s@%d = %s%s (%s)", p.Name, e, p.Format(p.Group.Get(e)), p.units(), p.Group.Label(e))
+	}
+	return s.String(), nil
+}
+
+// Split separates "<name...> <value>" arguments, for example
+// [Poisson Max 30], into the property name and the value's text.
+func Split(args []string) (name, value string, err error) {
+	if len(args) < 2 {
+		return "", "", fmt.Errorf("expected <property> <value>, got %v", args)
+	}
+	return strings.Join(args[:len(args)-1], " "), args[len(args)-1], nil
+}
+
+// List describes every property, one per line.
+func (r *Registry) List() string {
+	var s strings.Builder
+	for i, name := range r.Names() {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(r.describe(r.properties[name]))
+	}
+	return s.String()
+}
+
+func (r *Registry) describe(p *Property) string {
+	value, _ := r.Value(p.Name)
+	return fmt.Sprintf("%s = %s%s [%g, %g] step %g default %g",
+		p.Name, value, p.units(), p.Min, p.Max, p.Step, p.Default)
+}
+
+func (p *Property) units() string {
+	if p.Units == "" {
+		return ""
+	}
+	return " " + p.Units
+}
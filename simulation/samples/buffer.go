@@ -0,0 +1,214 @@
+package samples
+
+import (
+	"sync/atomic"
+)
+
+// Frame is a consistent set of samples, i.e. all of them were
+// collected up to the same sim time.
+type Frame struct {
+	Poi  *DatSamples
+	Stim *DatSamples
+	Cell *NeuronSamples
+
+	// The sim time (ms) of the most recent sample.
+	Time float64
+
+	// Property changes within the frame's samples, oldest first.
+	Changes []Change
+	// Pattern presentations that started within the frame's samples,
+	// oldest first.
+	Presentations []Presentation
+
+	// Incremented on every publish, readers can use it to skip
+	// rendering a frame they have already seen.
+	Seq int64
+}
+
+// NewFrame creates a frame for synCnt synapses and size samples.
+// Scrolling frames are ring buffers, see EnableScrolling.
+func NewFrame(synCnt, size int, scrolling bool) *Frame {
+	f := new(Frame)
+	f.Poi = NewDatSamples(synCnt, size)
+	f.Stim = NewDatSamples(synCnt, size)
+	f.Cell = NewNeuronSamples(size)
+
+	if scrolling {
+		f.Poi.EnableScrolling()
+		f.Stim.EnableScrolling()
+		f.Cell.EnableScrolling()
+	}
+
+	return f
+}
+
+// Change marks when a property changed so plots can show it.
+type Change struct {
+	// Sim time (ms) of the last sample before the change.
+	Time     float64 `json:"time"`
+	Property string  `json:"property"`
+	Value    string  `json:"value"`
+	Source   string  `json:"source"`
+}
+
+// Presentation marks when a pattern presentation started and which
+// pattern it was.
+type Presentation struct {
+	// Sim time (ms) of the presentation's first sample.
+	Time  float64 `json:"time"`
+	Label string  `json:"label"`
+}
+
+// Put records the time of the samples just collected. Changes and
+// presentations older than the frame's samples are dropped.
+func (f *Frame) Put(time float64) {
+	f.Time = time
+
+	oldest := time - float64(len(f.Cell.Samples))
+	drop := 0
+	for drop < len(f.Changes) && f.Changes[drop].Time <= oldest {
+		drop++
+	}
+	if drop > 0 {
+		f.Changes = append(f.Changes[:0], f.Changes[drop:]...)
+	}
+
+	drop = 0
+	for drop < len(f.Presentations) && f.Presentations[drop].Time <= oldest {
+		drop++
+	}
+	if drop > 0 {
+		f.Presentations = append(f.Presentations[:0], f.Presentations[drop:]...)
+	}
+}
+
+// Present records a presentation of the labeled pattern starting at
+// time.
+func (f *Frame) Present(time float64, label string) {
+	f.Presentations = append(f.Presentations, Presentation{Time: time, Label: label})
+}
+
+// ClearPresentations drops every presentation, for example when the
+// samples restart from t = 0.
+func (f *Frame) ClearPresentations() {
+	f.Presentations = f.Presentations[:0]
+}
+
+// Mark records a property change at the frame's current time.
+func (f *Frame) Mark(property, value, source string) {
+	f.Changes = append(f.Changes, Change{Time: f.Time, Property: property, Value: value, Source: source})
+}
+
+// ClearChanges drops every change, for example when the samples
+// restart from t = 0.
+func (f *Frame) ClearChanges() {
+	f.Changes = f.Changes[:0]
+}
+
+func (f *Frame) clone() *Frame {
+	c := NewFrame(f.Poi.synCnt, f.Poi.size, f.Poi.scrolling)
+	c.copyFrom(f)
+	return c
+}
+
+func (f *Frame) copyFrom(src *Frame) {
+	f.Poi.copyFrom(src.Poi)
+	f.Stim.copyFrom(src.Stim)
+	f.Cell.copyFrom(src.Cell)
+	f.Time = src.Time
+	f.Changes = append(f.Changes[:0], src.Changes...)
+	f.Presentations = append(f.Presentations[:0], src.Presentations...)
+	f.Seq = src.Seq
+}
+
+// Both sets of lanes were built by NewDatSamples with the same counts.
+func (s *DatSamples) copyFrom(src *DatSamples) {
+	dit := s.lanes.Iterator()
+	sit := src.lanes.Iterator()
+	for dit.Next() && sit.Next() {
+		dst := dit.Value().(*SamplesLane)
+		for i, sp := range sit.Value().(*SamplesLane).Samples {
+			*dst.Samples[i] = *sp
+		}
+	}
+	s.latest = src.latest
+}
+
+func (ns *NeuronSamples) copyFrom(src *NeuronSamples) {
+	for i, sp := range src.Samples {
+		*ns.Samples[i] = *sp
+	}
+	ns.latest = src.latest
+}
+
+// ---------------------------------------------------------
+// Buffer
+// ---------------------------------------------------------
+
+// The middle slot's index is packed with a flag marking it as
+// published but not yet acquired.
+const (
+	indexMask uint32 = 0x3
+	freshBit  uint32 = 0x4
+)
+
+// Buffer exchanges frames between the simulation goroutine and a
+// reader (the renderer or a recorder) without locks. It is a triple
+// buffer: the sim writes into Back(), Publish copies Back() into the
+// writer's spare frame and swaps it into the middle slot. Acquire
+// swaps the middle slot with the reader's frame when a newer one has
+// been published. Neither side ever waits on the other.
+type Buffer struct {
+	// The sim's working frame, only touched by the sim.
+	back *Frame
+
+	frames [3]*Frame
+
+	// Only touched by the writer.
+	spare uint32
+	// Only touched by the reader.
+	front uint32
+	// Exchanged atomically.
+	middle uint32
+}
+
+// NewBuffer creates a buffer whose working frame is back.
+func NewBuffer(back *Frame) *Buffer {
+	b := new(Buffer)
+	b.back = back
+
+	for i := range b.frames {
+		b.frames[i] = back.clone()
+	}
+
+	b.spare = 0
+	b.middle = 1
+	b.front = 2
+
+	return b
+}
+
+// Back is the frame the simulation writes samples into.
+func (b *Buffer) Back() *Frame {
+	return b.back
+}
+
+// Publish makes a copy of the working frame available to the reader.
+// It must only be called by the sim (writer) goroutine.
+func (b *Buffer) Publish() {
+	b.back.Seq++
+
+	b.frames[b.spare].copyFrom(b.back)
+	b.spare = atomic.SwapUint32(&b.middle, b.spare|freshBit) & indexMask
+}
+
+// Acquire returns the most recently published frame. The frame
+// belongs to the reader until its next Acquire.
+// It must only be called by the reader goroutine.
+func (b *Buffer) Acquire() *Frame {
+	if atomic.LoadUint32(&b.middle)&freshBit != 0 {
+		b.front = atomic.SwapUint32(&b.middle, b.front) & indexMask
+	}
+
+	return b.frames[b.front]
+}
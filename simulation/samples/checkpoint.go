@@ -0,0 +1,131 @@
+package samples
+
+import (
+	"fmt"
+)
+
+// Only spikes are checkpointed, all other samples are zero.
+
+// SpikeState is a sample holding a spike.
+type SpikeState struct {
+	Index int     `json:"index"`
+	Time  float64 `json:"time"`
+}
+
+// LaneState is the spikes of a single lane.
+type LaneState struct {
+	ID     int          `json:"id"`
+	Key    int          `json:"key"`
+	Spikes []SpikeState `json:"spikes"`
+}
+
+// FrameState is a Frame's checkpoint.
+type FrameState struct {
+	Time    float64     `json:"time"`
+	Seq     int64       `json:"seq"`
+	Latest  int         `json:"latest"`
+	Poisson []LaneState `json:"poisson"`
+	Stim    []LaneState `json:"stimulus"`
+	Cell    LaneState   `json:"cell"`
+	Changes []Change    `json:"changes,omitempty"`
+
+	Presentations []Presentation `json:"presentations,omitempty"`
+}
+
+func laneSnapshot(id, key int, spikes []*Spike) LaneState {
+	ls := LaneState{ID: id, Key: key}
+	for i, sp := range spikes {
+		if sp.Value == 1 {
+			ls.Spikes = append(ls.Spikes, SpikeState{Index: i, Time: sp.Time})
+		}
+	}
+	return ls
+}
+
+func laneRestore(ls LaneState, spikes []*Spike) error {
+	for _, sp := range spikes {
+		sp.Value = 0
+	}
+
+	for _, ss := range ls.Spikes {
+		if ss.Index < 0 || ss.Index >= len(spikes) {
+			return fmt.Errorf("sample index %d out of range [0, %d)", ss.Index, len(spikes))
+		}
+		sp := spikes[ss.Index]
+		sp.Value = 1
+		sp.Time = ss.Time
+		sp.Id = ls.ID
+		sp.Key = ls.Key
+	}
+
+	return nil
+}
+
+func (s *DatSamples) snapshot(key int) []LaneState {
+	lanes := []LaneState{}
+	it := s.lanes.Iterator()
+	for it.Next() {
+		lane := it.Value().(*SamplesLane)
+		lanes = append(lanes, laneSnapshot(lane.Id, key, lane.Samples))
+	}
+	return lanes
+}
+
+func (s *DatSamples) restore(lanes []LaneState, latest int) error {
+	if len(lanes) != s.lanes.Size() {
+		return fmt.Errorf("checkpoint has %d sample lanes, expected %d", len(lanes), s.lanes.Size())
+	}
+
+	it := s.lanes.Iterator()
+	for it.Next() {
+		lane := it.Value().(*SamplesLane)
+		if err := laneRestore(lanes[it.Index()], lane.Samples); err != nil {
+			return err
+		}
+	}
+
+	s.latest = latest
+	return nil
+}
+
+// Snapshot captures the frame's spikes.
+func (f *Frame) Snapshot() FrameState {
+	st := FrameState{
+		Time:    f.Time,
+		Seq:     f.Seq,
+		Latest:  f.Cell.latest,
+		Poisson: f.Poi.snapshot(3),
+		Stim:    f.Stim.snapshot(4),
+		Changes: append([]Change{}, f.Changes...),
+
+		Presentations: append([]Presentation{}, f.Presentations...),
+	}
+
+	id := 0
+	if len(f.Cell.Samples) > 0 {
+		id = f.Cell.Samples[0].Id
+	}
+	st.Cell = laneSnapshot(id, 0, f.Cell.Samples)
+
+	return st
+}
+
+// Restore expects a frame of the same size as the checkpointed frame.
+func (f *Frame) Restore(st FrameState) error {
+	if err := f.Poi.restore(st.Poisson, st.Latest); err != nil {
+		return err
+	}
+	if err := f.Stim.restore(st.Stim, st.Latest); err != nil {
+		return err
+	}
+	if err := laneRestore(st.Cell, f.Cell.Samples); err != nil {
+		return err
+	}
+
+	f.Cell.latest = st.Latest
+	f.Time = st.Time
+	f.Changes = append(f.Changes[:0], st.Changes...)
+	f.Presentations = append(f.Presentations[:0], st.Presentations...)
+	f.Seq = st.Seq
+	return nil
+}
@@ -0,0 +1,72 @@
+package samples
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteSpikes writes a csv row for every spike in the lanes:
+// cycle,time,source,id
+func (s *DatSamples) WriteSpikes(w io.Writer, cycle int, source string) error {
+	it := s.lanes.Iterator()
+	for it.Next() {
+		lane := it.Value().(*SamplesLane)
+		for _, sp := range lane.Samples {
+			if sp.Value == 1 {
+				_, err := fmt.Fprintf(w, "%d,%d,%s,%d\n", cycle, int(sp.Time), source, lane.Id)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SpikeCount returns the number of spikes across all lanes.
+func (s *DatSamples) SpikeCount() int {
+	cnt := 0
+	it := s.lanes.Iterator()
+	for it.Next() {
+		lane := it.Value().(*SamplesLane)
+		for _, sp := range lane.Samples {
+			cnt += int(sp.Value)
+		}
+	}
+	return cnt
+}
+
+// Rate is the average firing rate (Hz) of a lane, assuming 1ms samples.
+func (s *DatSamples) Rate() float64 {
+	if s.synCnt == 0 {
+		return 0.0
+	}
+	return float64(s.SpikeCount()) / float64(s.synCnt) / float64(s.size) * 1000.0
+}
+
+// WriteSpikes writes a csv row for every spike: cycle,time,source,id
+func (ns *NeuronSamples) WriteSpikes(w io.Writer, cycle int, source string) error {
+	for _, sp := range ns.Samples {
+		if sp.Value == 1 {
+			_, err := fmt.Fprintf(w, "%d,%d,%s,%d\n", cycle, int(sp.Time), source, sp.Id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SpikeCount returns the number of spikes.
+func (ns *NeuronSamples) SpikeCount() int {
+	cnt := 0
+	for _, sp := range ns.Samples {
+		cnt += int(sp.Value)
+	}
+	return cnt
+}
+
+// Rate is the firing rate (Hz), assuming 1ms samples.
+func (ns *NeuronSamples) Rate() float64 {
+	return float64(ns.SpikeCount()) / float64(len(ns.Samples)) * 1000.0
+}
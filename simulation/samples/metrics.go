@@ -0,0 +1,103 @@
+package samples
+
+import (
+	"math"
+)
+
+// SpikeTimes returns the times of the spikes, oldest first.
+func (ns *NeuronSamples) SpikeTimes() []float64 {
+	times := []float64{}
+	size := len(ns.Samples)
+	origin := ns.Origin()
+	for i := 0; i < size; i++ {
+		sp := ns.Samples[(origin+i)%size]
+		if sp.Value == 1 {
+			times = append(times, sp.Time)
+		}
+	}
+	return times
+}
+
+// ISIs returns the intervals (ms) between consecutive spikes.
+func (ns *NeuronSamples) ISIs() []float64 {
+	times := ns.SpikeTimes()
+	isis := []float64{}
+	for i := 1; i < len(times); i++ {
+		isis = append(isis, times[i]-times[i-1])
+	}
+	return isis
+}
+
+// CV is the coefficient of variation (stddev/mean) of intervals,
+// 0 if there are fewer than 2. Regular firing is 0, Poisson is ~1.
+func CV(isis []float64) float64 {
+	if len(isis) < 2 {
+		return 0.0
+	}
+
+	mean := 0.0
+	for _, isi := range isis {
+		mean += isi
+	}
+	mean /= float64(len(isis))
+
+	if mean == 0.0 {
+		return 0.0
+	}
+
+	variance := 0.0
+	for _, isi := range isis {
+		variance += (isi - mean) * (isi - mean)
+	}
+	variance /= float64(len(isis))
+
+	return math.Sqrt(variance) / mean
+}
+
+// PatternResponse counts the neuron's spikes that occurred within
+// window (ms) of a stimulus spike, i.e. while a pattern was being
+// presented. It returns those spikes and the total spikes along with
+// the samples spent inside and outside of presentations.
+func (f *Frame) PatternResponse(window int) (inSpikes, spikes, inSamples, outSamples int) {
+	size := len(f.Cell.Samples)
+	presenting := make([]bool, size)
+
+	it := f.Stim.lanes.Iterator()
+	for it.Next() {
+		lane := it.Value().(*SamplesLane)
+		for i, sp := range lane.Samples {
+			if sp.Value == 1 {
+				for k := 0; k < window && i+k < size; k++ {
+					presenting[i+k] = true
+				}
+			}
+		}
+	}
+
+	for i, sp := range f.Cell.Samples {
+		if presenting[i] {
+			inSamples++
+		} else {
+			outSamples++
+		}
+
+		if sp.Value == 1 {
+			spikes++
+			if presenting[i] {
+				inSpikes++
+			}
+		}
+	}
+
+	return inSpikes, spikes, inSamples, outSamples
+}
+
+// PatternRatio is the firing rate during pattern presentations relative
+// to the overall rate: 1 means the neuron ignores the pattern, larger
+// values mean it responds to it. It is 0 without spikes.
+func PatternRatio(inSpikes, spikes, inSamples, samples int) float64 {
+	if spikes == 0 || inSamples == 0 {
+		return 0.0
+	}
+	return (float64(inSpikes) / float64(inSamples)) / (float64(spikes) / float64(samples))
+}
@@ -87,9 +87,6 @@ func (s *Samples) Print() {
 // ---------------------------------------------------------
 // Data samples
 // ---------------------------------------------------------
-var PoiSamples *DatSamples
-var StimSamples *DatSamples
-
 type DatSamples struct {
 	// List of SamplesLane(s), for all passes.
 	// This data is used for rendering by the graphs.
@@ -98,6 +95,11 @@ type DatSamples struct {
 
 	synCnt int
 	size   int // typically the length of simulation
+
+	// When scrolling the lanes are ring buffers and the oldest sample
+	// follows the most recent one.
+	scrolling bool
+	latest    int
 }
 
 func NewDatSamples(synCnt, size int) *DatSamples {
@@ -128,14 +130,30 @@ func (s *DatSamples) Size() int {
 	return s.size
 }
 
+// EnableScrolling turns the lanes into ring buffers for simulations
+// that run longer than the sample size.
+func (s *DatSamples) EnableScrolling() {
+	s.scrolling = true
+}
+
+// Origin is the index of the oldest sample. Graphs start rendering here.
+func (s *DatSamples) Origin() int {
+	if !s.scrolling {
+		return 0
+	}
+	return (s.latest + 1) % s.size
+}
+
 func (s *DatSamples) Put(time float64, value byte, sid, key int) {
 	// sid is usually synId
 	_, lif := s.lanes.Find(func(id int, v interface{}) bool {
 		return v.(*SamplesLane).Id == sid
 	})
 
+	s.latest = int(time) % s.size
+
 	l := lif.(*SamplesLane)
-	sp := l.Samples[int(time)]
+	sp := l.Samples[s.latest]
 	sp.Time = time
 	sp.Value = value
 	sp.Id = sid
@@ -145,10 +163,11 @@ func (s *DatSamples) Put(time float64, value byte, sid, key int) {
 // ---------------------------------------------------------
 // Neuron samples
 // ---------------------------------------------------------
-var CellSamples *NeuronSamples
-
 type NeuronSamples struct {
 	Samples []*Spike
+
+	scrolling bool
+	latest    int
 }
 
 func NewNeuronSamples(size int) *NeuronSamples {
@@ -162,8 +181,22 @@ func NewNeuronSamples(size int) *NeuronSamples {
 	return ns
 }
 
+// EnableScrolling turns the samples into a ring buffer.
+func (ns *NeuronSamples) EnableScrolling() {
+	ns.scrolling = true
+}
+
+// Origin is the index of the oldest sample.
+func (ns *NeuronSamples) Origin() int {
+	if !ns.scrolling {
+		return 0
+	}
+	return (ns.latest + 1) % len(ns.Samples)
+}
+
 func (ns *NeuronSamples) Put(time float64, value byte, sid, key int) {
-	sp := ns.Samples[int(time)]
+	ns.latest = int(time) % len(ns.Samples)
+	sp := ns.Samples[ns.latest]
 	sp.Time = time
 	sp.Value = value
 	sp.Id = sid
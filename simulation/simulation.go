@@ -0,0 +1,120 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+// ISimulation is implemented by every simulation type the App can host.
+// The App talks to a simulation through Commands and receives
+// Status replies and PropertyChange events back on channels.
+type ISimulation interface {
+	// Connect supplies the channels the simulation responds on.
+	Connect(statusChannel chan Status, propEventChannel chan PropertyChange)
+
+	// Command handles a request, for example: prop Poisson Max 30
+	// Exactly one Status with the command's ID is sent in reply.
+	Command(cmd Command)
+
+	// Load reads a json definition relative to the working directory.
+	Load(name string) error
+
+	// Definition is the loaded definition, used by the next Create().
+	Definition() *config.Definition
+
+	// Create builds the network described by the loaded definition.
+	// It fails while the simulation is running.
+	Create() error
+
+	// Step makes a single pass while paused or stopped.
+	Step() error
+
+	// Tick is a single iteration of the run loop. It returns true when
+	// a run (or window) of samples is complete. Drivers without a GUI,
+	// for example the headless runner, call it directly instead of
+	// starting the run loop.
+	Tick() bool
+
+	// RunPause runs a complete run (or window) and then pauses.
+	RunPause() error
+
+	Reset() error
+
+	// Save writes a checkpoint of the complete simulation.
+	Save(path string) error
+
+	// Restore re-creates the simulation from a checkpoint written by
+	// Save. Continuing gives the same results as the original run.
+	Restore(path string) error
+
+	// Seeds returns the seed of every random stream, for recording
+	// with a run's results.
+	Seeds() map[string]int64
+
+	// State is the run loop's state.
+	State() State
+
+	// Samples is the buffer frames are acquired from for rendering
+	// or recording. It is nil until Create() and replaced by each Create().
+	Samples() *samples.Buffer
+
+	// Properties are the created network's tunable parameters, nil
+	// before Create(). They must only be used directly while the run
+	// loop isn't running, otherwise use the prop command.
+	Properties() *property.Registry
+
+	// Snapshot captures every property's value, for presets.
+	Snapshot() (property.Preset, error)
+
+	// Diff describes how a preset differs from the current values of
+	// the simulation's properties.
+	Diff(preset property.Preset) ([]string, error)
+
+	RequestProperty(property string) string
+
+	// SetCommand remembers the active property for up/down nudges.
+	SetCommand(cmd []string)
+}
+
+// Factory creates a new, unconnected, simulation.
+type Factory func() ISimulation
+
+var factories = map[string]Factory{}
+
+// Register makes a simulation type available by name. Simulation
+// packages register themselves during init().
+func Register(name string, factory Factory) {
+	if _, dup := factories[name]; dup {
+		panic(fmt.Sprintf("Simulation: `%s` registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// New creates a simulation of the named type.
+func New(name string) (ISimulation, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown simulation type `%s`, available: %v", name, Names())
+	}
+	return factory(), nil
+}
+
+// Has reports if a simulation type is registered.
+func Has(name string) bool {
+	_, ok := factories[name]
+	return ok
+}
+
+// Names returns the registered simulation types, sorted.
+func Names() []string {
+	names := []string{}
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
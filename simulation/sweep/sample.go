@@ -0,0 +1,96 @@
+package sweep
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/wdevore/Deuron4/deuron/rng"
+)
+
+// Points returns the configurations to run, one value per parameter.
+func (s *Spec) Points() [][]float64 {
+	ran := rng.NewManager(s.Seed).Rand("sweep")
+
+	switch s.Method {
+	case Random:
+		return s.random(ran)
+	case LHS:
+		return s.lhs(ran)
+	}
+
+	return s.grid()
+}
+
+// values lists a parameter's grid values.
+func (p Parameter) values() []float64 {
+	if len(p.Values) > 0 {
+		return p.Values
+	}
+
+	if p.Steps == 1 {
+		return []float64{p.Min}
+	}
+
+	values := make([]float64, p.Steps)
+	for i := range values {
+		values[i] = p.Min + (p.Max-p.Min)*float64(i)/float64(p.Steps-1)
+	}
+	return values
+}
+
+// at maps u, within [0, 1), onto the parameter's range or values.
+// Every whole value of a whole parameter's range is equally likely.
+func (p Parameter) at(u float64) float64 {
+	if len(p.Values) > 0 {
+		return p.Values[int(u*float64(len(p.Values)))]
+	}
+	if p.whole {
+		return math.Floor(p.Min + (p.Max-p.Min+1)*u)
+	}
+	return p.Min + (p.Max-p.Min)*u
+}
+
+// grid is every combination of every parameter's values.
+func (s *Spec) grid() [][]float64 {
+	points := [][]float64{{}}
+
+	for _, p := range s.Parameters {
+		next := [][]float64{}
+		for _, point := range points {
+			for _, v := range p.values() {
+				np := append(append([]float64{}, point...), v)
+				next = append(next, np)
+			}
+		}
+		points = next
+	}
+
+	return points
+}
+
+func (s *Spec) random(ran *rand.Rand) [][]float64 {
+	points := make([][]float64, s.Samples)
+	for i := range points {
+		for _, p := range s.Parameters {
+			points[i] = append(points[i], p.at(ran.Float64()))
+		}
+	}
+	return points
+}
+
+// lhs divides each parameter into Samples strata and takes one value
+// from each stratum, pairing the strata across parameters at random.
+func (s *Spec) lhs(ran *rand.Rand) [][]float64 {
+	points := make([][]float64, s.Samples)
+	n := float64(s.Samples)
+
+	for _, p := range s.Parameters {
+		perm := ran.Perm(s.Samples)
+		for i := range points {
+			u := (float64(perm[i]) + ran.Float64()) / n
+			points[i] = append(points[i], p.at(u))
+		}
+	}
+
+	return points
+}
@@ -0,0 +1,47 @@
+package sweep
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_WholeParametersSampleWholeValues(t *testing.T) {
+	cases := []struct {
+		method string
+		whole  bool
+	}{
+		{Random, true},
+		{LHS, true},
+		{Random, false},
+	}
+
+	for _, c := range cases {
+		s := NewSpec()
+		s.Method = c.method
+		s.Samples = 200
+		s.Parameters = []Parameter{{Property: "Burst Spikes", Min: 1, Max: 4, whole: c.whole}}
+
+		seen := map[float64]bool{}
+		fractional := false
+		for _, point := range s.Points() {
+			v := point[0]
+			if v < 1 || v > 4 {
+				t.Errorf("%s: %g is outside [1, 4]", c.method, v)
+			}
+			if v != math.Trunc(v) {
+				fractional = true
+			}
+			seen[v] = true
+		}
+
+		if c.whole && fractional {
+			t.Errorf("%s: sampled fractional values of a whole parameter", c.method)
+		}
+		if c.whole && len(seen) != 4 {
+			t.Errorf("%s: sampled %d of the 4 whole values", c.method, len(seen))
+		}
+		if !c.whole && !fractional {
+			t.Errorf("%s: expected fractional values", c.method)
+		}
+	}
+}
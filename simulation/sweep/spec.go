@@ -0,0 +1,191 @@
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/property"
+)
+
+// Sampling methods
+const (
+	Grid   = "grid"
+	Random = "random"
+	LHS    = "lhs"
+)
+
+// Spec describes a sweep, typically loaded from json:
+//
+//	{
+//	  "type": "runreset",
+//	  "definition": "runreset.json",
+//	  "cycles": 10,
+//	  "method": "lhs",
+//	  "samples": 50,
+//	  "parameters": [
+//	    {"property": "Poisson Max", "min": 50, "max": 500},
+//	    {"property": "Poisson Min", "values": [5, 7, 10, 20]}
+//	  ]
+//	}
+type Spec struct {
+	// Simulation type, defaults to runreset.
+	Type string `json:"type"`
+	// Optional definition file, defaults are used if empty.
+	Definition string `json:"definition"`
+
+	// Run-reset cycles (or windows) per configuration.
+	Cycles int `json:"cycles"`
+
+	// grid, random or lhs (Latin hypercube).
+	Method string `json:"method"`
+	// Number of configurations for random and lhs.
+	Samples int `json:"samples"`
+	// Seeds the random and lhs sampling.
+	Seed int64 `json:"seed"`
+
+	// Number of configurations run in parallel, 0 is one per CPU.
+	Workers int `json:"workers"`
+
+	Parameters []Parameter `json:"parameters"`
+}
+
+// Parameter is a simulation property and the values to sweep it over.
+// Either Values are listed or a [Min, Max] range is given. Grids
+// divide a range into Steps values.
+type Parameter struct {
+	// A property listed by the `props` command, e.g. "Poisson Max".
+	Property string    `json:"property"`
+	Values   []float64 `json:"values"`
+	Min      float64   `json:"min"`
+	Max      float64   `json:"max"`
+	Steps    int       `json:"steps"`
+
+	// The property takes whole values only.
+	whole bool
+}
+
+// NewSpec returns a spec with default values.
+func NewSpec() *Spec {
+	s := new(Spec)
+	s.Type = "runreset"
+	s.Cycles = 1
+	s.Method = Grid
+	s.Samples = 10
+	s.Seed = 1
+	return s
+}
+
+// LoadSpec reads and validates a sweep file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSpec()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if err = s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s:\n%v", path, err)
+	}
+
+	return s, nil
+}
+
+// Validate checks the spec's values.
+func (s *Spec) Validate() error {
+	var errs config.Errors
+
+	add := func(path, format string, a ...interface{}) {
+		errs = append(errs, &config.PathError{Path: path, Msg: fmt.Sprintf(format, a...)})
+	}
+
+	// Parameters are checked against the simulation's properties.
+	var props *property.Registry
+	if !simulation.Has(s.Type) {
+		add("$.type", "unknown simulation type `%s`, available: %v", s.Type, simulation.Names())
+	} else {
+		var err error
+		if props, err = Properties(s.Type, s.Definition); err != nil {
+			add("$.definition", "%v", err)
+		}
+	}
+	if s.Cycles <= 0 {
+		add("$.cycles", "must be > 0, got %d", s.Cycles)
+	}
+
+	switch s.Method {
+	case Grid:
+	case Random, LHS:
+		if s.Samples <= 0 {
+			add("$.samples", "must be > 0, got %d", s.Samples)
+		}
+	default:
+		add("$.method", "unknown method `%s`, expected grid, random or lhs", s.Method)
+	}
+
+	if s.Workers < 0 {
+		add("$.workers", "must be >= 0, got %d", s.Workers)
+	}
+
+	if len(s.Parameters) == 0 {
+		add("$.parameters", "no parameters to sweep")
+	}
+
+	for i, p := range s.Parameters {
+		path := fmt.Sprintf("$.parameters[%d]", i)
+		if props != nil {
+			s.Parameters[i].whole = s.checkProperty(props, p, path, add)
+		}
+		if len(p.Values) > 0 {
+			continue
+		}
+		if p.Max < p.Min {
+			add(path, "max %g is less than min %g", p.Max, p.Min)
+		}
+		if s.Method == Grid && p.Steps <= 0 {
+			add(path+".steps", "grids need values or steps > 0")
+		}
+		if s.Method == Grid && s.Parameters[i].whole && p.Steps > 1 && math.Mod(p.Max-p.Min, float64(p.Steps-1)) != 0 {
+			add(path+".steps", "%s takes whole values but the grid steps by %g", p.Property, (p.Max-p.Min)/float64(p.Steps-1))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// checkProperty checks that a parameter names a property and that its
+// values are within the property's range. It reports if the property
+// takes whole values only.
+func (s *Spec) checkProperty(props *property.Registry, p Parameter, path string, add func(path, format string, a ...interface{})) bool {
+	prop, err := props.Find(p.Property)
+	if err != nil {
+		add(path+".property", "%v", err)
+		return false
+	}
+
+	values := p.Values
+	if len(values) == 0 {
+		values = []float64{p.Min, p.Max}
+	}
+	for _, v := range values {
+		if err = prop.Check(v); err != nil {
+			add(path, "%v", err)
+			break
+		}
+	}
+	return prop.Type == property.Int
+}
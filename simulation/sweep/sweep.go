@@ -0,0 +1,250 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/samples"
+)
+
+// Result is the outcome of running a single configuration.
+type Result struct {
+	Run    int
+	Values []float64
+
+	Cycles int
+	// Averaged over the cycles.
+	NoiseRate  float64
+	StimRate   float64
+	OutputRate float64
+	OutSpikes  int
+
+	// Coefficient of variation of the output ISIs.
+	CVISI float64
+	// Output rate during pattern presentations relative to the
+	// overall output rate, see samples.PatternRatio.
+	PatternRatio float64
+
+	Err error
+}
+
+// Metrics are the names accepted by Result.Metric.
+var Metrics = []string{"outputRate", "noiseRate", "stimRate", "cvISI", "patternRatio"}
+
+// Metric returns a result value by name.
+func (r *Result) Metric(name string) (float64, error) {
+	switch name {
+	case "outputRate":
+		return r.OutputRate, nil
+	case "noiseRate":
+		return r.NoiseRate, nil
+	case "stimRate":
+		return r.StimRate, nil
+	case "cvISI":
+		return r.CVISI, nil
+	case "patternRatio":
+		return r.PatternRatio, nil
+	}
+	return 0, fmt.Errorf("unknown metric `%s`, expected one of %v", name, Metrics)
+}
+
+// Run runs every configuration, Workers at a time, and calls progress
+// (if not nil) as each one completes. Results are in configuration order.
+func Run(spec *Spec, progress func(done, total int, result *Result)) []*Result {
+	points := spec.Points()
+	results := make([]*Result, len(points))
+
+	workers := spec.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	properties := []string{}
+	for _, p := range spec.Parameters {
+		properties = append(properties, p.Property)
+	}
+
+	jobs := make(chan int)
+	var mutex sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range jobs {
+				result := Evaluate(spec.Type, spec.Definition, spec.Cycles, properties, points[run])
+				result.Run = run
+
+				mutex.Lock()
+				results[run] = result
+				done++
+				if progress != nil {
+					progress(done, len(points), result)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for run := range points {
+		jobs <- run
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Evaluate runs a configuration, where each property is set to its
+// value, on its own simulation for a number of cycles. Simulations
+// share nothing so configurations can be evaluated in parallel.
+func Evaluate(simType, definition string, cycles int, properties []string, values []float64) *Result {
+	result := &Result{Values: values}
+
+	sim, err := simulation.New(simType)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	statusComm := make(chan simulation.Status)
+	propEventComm := make(chan simulation.PropertyChange)
+	defer close(propEventComm)
+	go func() {
+		for range propEventComm {
+		}
+	}()
+	sim.Connect(statusComm, propEventComm)
+
+	if definition != "" {
+		if err = sim.Load(definition); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	if err = sim.Create(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	for i, property := range properties {
+		args := append(strings.Fields(property), strconv.FormatFloat(values[i], 'g', -1, 64))
+		if err = request(sim, statusComm, simulation.NewCommand("prop", args...)); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	// A pattern response is a spike within a pattern's length of a
+	// stimulus spike.
+	window := sim.Definition().Pattern.Width()
+
+	isis := []float64{}
+	var inSpikes, spikes, inSamples, allSamples int
+
+	for result.Cycles < cycles {
+		if !sim.Tick() {
+			continue
+		}
+
+		frame := sim.Samples().Acquire()
+		result.NoiseRate += frame.Poi.Rate()
+		result.StimRate += frame.Stim.Rate()
+		result.OutputRate += frame.Cell.Rate()
+		result.OutSpikes += frame.Cell.SpikeCount()
+		result.Cycles++
+
+		isis = append(isis, frame.Cell.ISIs()...)
+
+		in, all, inS, outS := frame.PatternResponse(window)
+		inSpikes += in
+		spikes += all
+		inSamples += inS
+		allSamples += inS + outS
+	}
+
+	result.NoiseRate /= float64(result.Cycles)
+	result.StimRate /= float64(result.Cycles)
+	result.OutputRate /= float64(result.Cycles)
+	result.CVISI = samples.CV(isis)
+	result.PatternRatio = samples.PatternRatio(inSpikes, spikes, inSamples, allSamples)
+
+	return result
+}
+
+// Properties creates a simulation of simType from definition, without
+// running it, and returns its properties.
+func Properties(simType, definition string) (*property.Registry, error) {
+	sim, err := simulation.New(simType)
+	if err != nil {
+		return nil, err
+	}
+
+	if definition != "" {
+		if err = sim.Load(definition); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = sim.Create(); err != nil {
+		return nil, err
+	}
+
+	return sim.Properties(), nil
+}
+
+// request sends a command and waits for its reply. Nothing else is
+// running so the only status is the reply.
+func request(sim simulation.ISimulation, statusComm chan simulation.Status, cmd simulation.Command) error {
+	sim.Command(cmd)
+
+	for status := range statusComm {
+		if status.ID == cmd.ID {
+			return status.Err
+		}
+	}
+
+	return fmt.Errorf("no reply to `%s`", cmd.Name)
+}
+
+// WriteCSV writes the results table, one row per configuration.
+func WriteCSV(w io.Writer, spec *Spec, results []*Result) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"run"}
+	for _, p := range spec.Parameters {
+		header = append(header, p.Property)
+	}
+	header = append(header, "cycles", "noiseRateHz", "stimRateHz", "outputRateHz", "outputSpikes", "cvISI", "patternRatio", "error")
+	cw.Write(header)
+
+	for _, r := range results {
+		row := []string{strconv.Itoa(r.Run)}
+		for _, v := range r.Values {
+			row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+
+		row = append(row, strconv.Itoa(r.Cycles),
+			fmt.Sprintf("%f", r.NoiseRate), fmt.Sprintf("%f", r.StimRate), fmt.Sprintf("%f", r.OutputRate),
+			strconv.Itoa(r.OutSpikes), fmt.Sprintf("%f", r.CVISI), fmt.Sprintf("%f", r.PatternRatio), errMsg)
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
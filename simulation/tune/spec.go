@@ -0,0 +1,209 @@
+package tune
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/config"
+	"github.com/wdevore/Deuron4/simulation/property"
+	"github.com/wdevore/Deuron4/simulation/sweep"
+)
+
+// Methods
+const (
+	Bisection  = "bisection"
+	NelderMead = "nelder-mead"
+)
+
+// Spec describes what to tune and the targets to reach, typically
+// loaded from json:
+//
+//	{
+//	  "type": "runreset",
+//	  "definition": "runreset.json",
+//	  "cycles": 5,
+//	  "method": "nelder-mead",
+//	  "parameters": [
+//	    {"property": "Poisson Max", "min": 50, "max": 500, "start": 300}
+//	  ],
+//	  "targets": [
+//	    {"metric": "noiseRate", "value": 20, "tolerance": 0.5}
+//	  ]
+//	}
+type Spec struct {
+	// What the spec is for, it isn't used.
+	Note string `json:"note,omitempty"`
+
+	Type       string `json:"type"`
+	Definition string `json:"definition"`
+
+	// Run-reset cycles (or windows) per evaluation.
+	Cycles int `json:"cycles"`
+
+	// bisection (one parameter and one target) or nelder-mead.
+	Method string `json:"method"`
+
+	// Evaluations before giving up.
+	MaxEvaluations int `json:"maxEvaluations"`
+
+	Parameters []Parameter `json:"parameters"`
+	Targets    []Target    `json:"targets"`
+}
+
+// Parameter is a property the tuner may adjust within [Min, Max].
+type Parameter struct {
+	// A property listed by the `props` command, e.g. "Poisson Max".
+	Property string  `json:"property"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	// Where nelder-mead starts, defaults to the middle of the range.
+	Start *float64 `json:"start"`
+
+	// The property takes whole values only.
+	whole bool
+}
+
+// Target is a metric's desired value. A target is met when the metric
+// is within Tolerance of Value.
+type Target struct {
+	// One of sweep.Metrics, e.g. outputRate, cvISI or patternRatio.
+	// The output metrics need a neuron model that fires.
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Tolerance float64 `json:"tolerance"`
+	// Relative importance when there are several targets, default 1.
+	Weight float64 `json:"weight"`
+}
+
+// NewSpec returns a spec with default values.
+func NewSpec() *Spec {
+	s := new(Spec)
+	s.Type = "runreset"
+	s.Cycles = 5
+	s.Method = NelderMead
+	s.MaxEvaluations = 50
+	return s
+}
+
+// LoadSpec reads and validates a tuning file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSpec()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	for i := range s.Targets {
+		if s.Targets[i].Weight == 0 {
+			s.Targets[i].Weight = 1
+		}
+	}
+
+	if err = s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s:\n%v", path, err)
+	}
+
+	return s, nil
+}
+
+// Validate checks the spec's values.
+func (s *Spec) Validate() error {
+	var errs config.Errors
+
+	add := func(path, format string, a ...interface{}) {
+		errs = append(errs, &config.PathError{Path: path, Msg: fmt.Sprintf(format, a...)})
+	}
+
+	// Parameters are checked against the simulation's properties.
+	var props *property.Registry
+	if !simulation.Has(s.Type) {
+		add("$.type", "unknown simulation type `%s`, available: %v", s.Type, simulation.Names())
+	} else {
+		var err error
+		if props, err = sweep.Properties(s.Type, s.Definition); err != nil {
+			add("$.definition", "%v", err)
+		}
+	}
+	if s.Cycles <= 0 {
+		add("$.cycles", "must be > 0, got %d", s.Cycles)
+	}
+	if s.MaxEvaluations <= 0 {
+		add("$.maxEvaluations", "must be > 0, got %d", s.MaxEvaluations)
+	}
+
+	switch s.Method {
+	case Bisection:
+		if len(s.Parameters) != 1 || len(s.Targets) != 1 {
+			add("$.method", "bisection tunes exactly one parameter to one target")
+		}
+	case NelderMead:
+	default:
+		add("$.method", "unknown method `%s`, expected bisection or nelder-mead", s.Method)
+	}
+
+	if len(s.Parameters) == 0 {
+		add("$.parameters", "no parameters to tune")
+	}
+	for i, p := range s.Parameters {
+		path := fmt.Sprintf("$.parameters[%d]", i)
+		if props != nil {
+			s.Parameters[i].whole = s.checkProperty(props, p, path, add)
+		}
+		if p.Max <= p.Min {
+			add(path, "max %g must be greater than min %g", p.Max, p.Min)
+		}
+		if p.Start != nil && (*p.Start < p.Min || *p.Start > p.Max) {
+			add(path+".start", "%g is outside [%g, %g]", *p.Start, p.Min, p.Max)
+		}
+	}
+
+	if len(s.Targets) == 0 {
+		add("$.targets", "no targets")
+	}
+	for i, t := range s.Targets {
+		path := fmt.Sprintf("$.targets[%d]", i)
+		if _, err := (&sweep.Result{}).Metric(t.Metric); err != nil {
+			add(path+".metric", "%v", err)
+		}
+		if t.Tolerance < 0 {
+			add(path+".tolerance", "must be >= 0, got %g", t.Tolerance)
+		}
+		if t.Weight < 0 {
+			add(path+".weight", "must be >= 0, got %g", t.Weight)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// checkProperty checks that a parameter names a property and that its
+// range is within the property's. It reports if the property takes
+// whole values only.
+func (s *Spec) checkProperty(props *property.Registry, p Parameter, path string, add func(path, format string, a ...interface{})) bool {
+	prop, err := props.Find(p.Property)
+	if err != nil {
+		add(path+".property", "%v", err)
+		return false
+	}
+
+	for _, v := range []float64{p.Min, p.Max} {
+		if err = prop.Check(v); err != nil {
+			add(path, "%v", err)
+			break
+		}
+	}
+	return prop.Type == property.Int
+}
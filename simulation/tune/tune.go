@@ -0,0 +1,378 @@
+package tune
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/wdevore/Deuron4/simulation/sweep"
+)
+
+// Step is a single evaluation on the tuner's trajectory.
+type Step struct {
+	Evaluation int
+	Values     []float64
+	Result     *sweep.Result
+
+	// Weighted squared distance from the targets, in tolerances.
+	Error float64
+	// All targets are within tolerance.
+	Met bool
+}
+
+// Tuner adjusts the spec's parameters until its targets are met.
+// Every evaluation runs fresh simulations for the spec's cycles, so
+// evaluations are repeatable and the objective isn't noisy.
+type Tuner struct {
+	spec       *Spec
+	properties []string
+
+	// Called after each evaluation, may be nil.
+	log func(step *Step)
+
+	Trajectory []*Step
+	best       *Step
+}
+
+// NewTuner creates a tuner, log is called for every evaluation.
+func NewTuner(spec *Spec, log func(step *Step)) *Tuner {
+	t := new(Tuner)
+	t.spec = spec
+	t.log = log
+	for _, p := range spec.Parameters {
+		t.properties = append(t.properties, p.Property)
+	}
+	return t
+}
+
+// Run tunes and returns the best step found. The step's Met is false
+// if the targets couldn't be reached within MaxEvaluations. It fails
+// if a target's metric never changed, the best step is then
+// meaningless.
+func (t *Tuner) Run() (*Step, error) {
+	var err error
+
+	switch t.spec.Method {
+	case Bisection:
+		err = t.bisection()
+	default:
+		err = t.nelderMead()
+	}
+
+	if t.best != nil && !t.best.Met {
+		if ferr := t.flat(); ferr != nil {
+			return t.best, ferr
+		}
+	}
+
+	if err != nil && err != errDone {
+		return t.best, err
+	}
+
+	return t.best, nil
+}
+
+// errDone stops a method once the targets are met or the
+// evaluations are used up.
+var errDone = fmt.Errorf("done")
+
+func (t *Tuner) evaluate(values []float64) (*Step, error) {
+	if len(t.Trajectory) >= t.spec.MaxEvaluations {
+		return nil, errDone
+	}
+
+	for i, p := range t.spec.Parameters {
+		values[i] = math.Max(p.Min, math.Min(p.Max, values[i]))
+		if p.whole {
+			values[i] = math.Round(values[i])
+		}
+	}
+
+	result := sweep.Evaluate(t.spec.Type, t.spec.Definition, t.spec.Cycles, t.properties, values)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	step := &Step{Evaluation: len(t.Trajectory), Values: values, Result: result, Met: true}
+	for _, target := range t.spec.Targets {
+		m, _ := result.Metric(target.Metric)
+
+		scale := target.Tolerance
+		if scale == 0 {
+			scale = math.Max(math.Abs(target.Value), 1.0)
+		}
+		d := (m - target.Value) / scale
+		step.Error += target.Weight * d * d
+
+		if math.Abs(m-target.Value) > target.Tolerance {
+			step.Met = false
+		}
+	}
+
+	t.Trajectory = append(t.Trajectory, step)
+	if t.best == nil || step.Error < t.best.Error {
+		t.best = step
+	}
+
+	if t.log != nil {
+		t.log(step)
+	}
+
+	if step.Met {
+		return step, errDone
+	}
+
+	return step, nil
+}
+
+// flat reports a target whose metric had the same value for every
+// evaluation although the parameters changed.
+func (t *Tuner) flat() error {
+	if len(t.Trajectory) < 2 {
+		return nil
+	}
+
+	first := t.Trajectory[0]
+	moved := false
+	for _, s := range t.Trajectory[1:] {
+		for i := range s.Values {
+			if s.Values[i] != first.Values[i] {
+				moved = true
+			}
+		}
+	}
+	if !moved {
+		return nil
+	}
+
+	for _, target := range t.spec.Targets {
+		m, _ := first.Result.Metric(target.Metric)
+		changed := false
+		for _, s := range t.Trajectory[1:] {
+			if v, _ := s.Result.Metric(target.Metric); v != m {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return fmt.Errorf("%s was %g for all %d evaluations, the parameters don't affect it. Output targets (outputRate, cvISI, patternRatio) need a neuron model that fires",
+				target.Metric, m, len(t.Trajectory))
+		}
+	}
+
+	return nil
+}
+
+// bisection assumes the target metric moves monotonically with the
+// parameter and that the range brackets the target.
+func (t *Tuner) bisection() error {
+	p := t.spec.Parameters[0]
+	target := t.spec.Targets[0]
+
+	offset := func(s *Step) float64 {
+		m, _ := s.Result.Metric(target.Metric)
+		return m - target.Value
+	}
+
+	lo, hi := p.Min, p.Max
+
+	sLo, err := t.evaluate([]float64{lo})
+	if err != nil {
+		return err
+	}
+	sHi, err := t.evaluate([]float64{hi})
+	if err != nil {
+		return err
+	}
+
+	fLo := offset(sLo)
+	if (fLo > 0) == (offset(sHi) > 0) {
+		return fmt.Errorf("%s isn't bracketed by %s [%g, %g]: %g and %g from target",
+			target.Metric, p.Property, lo, hi, fLo, offset(sHi))
+	}
+
+	for {
+		mid := (lo + hi) / 2
+		s, err := t.evaluate([]float64{mid})
+		if err != nil {
+			return err
+		}
+
+		if (offset(s) > 0) == (fLo > 0) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+}
+
+type vertex struct {
+	u    []float64 // normalized to [0, 1]
+	cost float64
+}
+
+// nelderMead minimizes the step error over the parameters normalized
+// to [0, 1]. Points outside the range are clamped.
+func (t *Tuner) nelderMead() error {
+	params := t.spec.Parameters
+	n := len(params)
+
+	cost := func(u []float64) (vertex, error) {
+		values := make([]float64, n)
+		for i, p := range params {
+			u[i] = math.Max(0, math.Min(1, u[i]))
+			values[i] = p.Min + u[i]*(p.Max-p.Min)
+		}
+
+		s, err := t.evaluate(values)
+		if err != nil {
+			return vertex{}, err
+		}
+		return vertex{u: u, cost: s.Error}, nil
+	}
+
+	// Initial simplex around the start.
+	start := make([]float64, n)
+	for i, p := range params {
+		start[i] = 0.5
+		if p.Start != nil {
+			start[i] = (*p.Start - p.Min) / (p.Max - p.Min)
+		}
+	}
+
+	simplex := []vertex{}
+	v, err := cost(start)
+	if err != nil {
+		return err
+	}
+	simplex = append(simplex, v)
+
+	for i := 0; i < n; i++ {
+		u := append([]float64{}, start...)
+		if u[i]+0.25 <= 1 {
+			u[i] += 0.25
+		} else {
+			u[i] -= 0.25
+		}
+		v, err := cost(u)
+		if err != nil {
+			return err
+		}
+		simplex = append(simplex, v)
+	}
+
+	// towards returns a + k(b - a)
+	towards := func(a, b []float64, k float64) []float64 {
+		u := make([]float64, n)
+		for i := range u {
+			u[i] = a[i] + k*(b[i]-a[i])
+		}
+		return u
+	}
+
+	for {
+		sort.Slice(simplex, func(i, j int) bool { return simplex[i].cost < simplex[j].cost })
+
+		best, worst := simplex[0], simplex[n]
+		if worst.cost-best.cost < 1e-9 && size(simplex) < 1e-4 {
+			// Converged, but not onto the targets.
+			return nil
+		}
+
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for i := range centroid {
+				centroid[i] += v.u[i] / float64(n)
+			}
+		}
+
+		reflected, err := cost(towards(centroid, worst.u, -1))
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case reflected.cost < best.cost:
+			expanded, err := cost(towards(centroid, worst.u, -2))
+			if err != nil {
+				return err
+			}
+			if expanded.cost < reflected.cost {
+				simplex[n] = expanded
+			} else {
+				simplex[n] = reflected
+			}
+		case reflected.cost < simplex[n-1].cost:
+			simplex[n] = reflected
+		default:
+			contracted, err := cost(towards(centroid, worst.u, 0.5))
+			if err != nil {
+				return err
+			}
+			if contracted.cost < worst.cost {
+				simplex[n] = contracted
+				continue
+			}
+
+			// Shrink towards the best.
+			for i := 1; i <= n; i++ {
+				simplex[i], err = cost(towards(best.u, simplex[i].u, 0.5))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// size is the simplex's largest extent in any dimension.
+func size(simplex []vertex) float64 {
+	s := 0.0
+	for i := range simplex[0].u {
+		lo, hi := simplex[0].u[i], simplex[0].u[i]
+		for _, v := range simplex {
+			lo = math.Min(lo, v.u[i])
+			hi = math.Max(hi, v.u[i])
+		}
+		s = math.Max(s, hi-lo)
+	}
+	return s
+}
+
+// WriteTrajectory writes every evaluation as a csv row.
+func WriteTrajectory(w io.Writer, spec *Spec, steps []*Step) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"evaluation"}
+	for _, p := range spec.Parameters {
+		header = append(header, p.Property)
+	}
+	for _, m := range sweep.Metrics {
+		header = append(header, m)
+	}
+	header = append(header, "error", "met")
+	cw.Write(header)
+
+	for _, s := range steps {
+		row := []string{strconv.Itoa(s.Evaluation)}
+		for _, v := range s.Values {
+			row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		for _, m := range sweep.Metrics {
+			value, _ := s.Result.Metric(m)
+			row = append(row, fmt.Sprintf("%f", value))
+		}
+		row = append(row, fmt.Sprintf("%f", s.Error), strconv.FormatBool(s.Met))
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *Step) String() string {
+	return fmt.Sprintf("#%d %v error %0.4f met %v", s.Evaluation, s.Values, s.Error, s.Met)
+}
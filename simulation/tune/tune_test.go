@@ -0,0 +1,47 @@
+package tune
+
+import (
+	"testing"
+
+	"github.com/wdevore/Deuron4/simulation/sweep"
+)
+
+func Test_FlatTargets(t *testing.T) {
+	type eval struct {
+		value  float64
+		output float64
+		noise  float64
+	}
+
+	cases := []struct {
+		name   string
+		metric string
+		evals  []eval
+		flat   bool
+	}{
+		{"single evaluation", "outputRate", []eval{{100, 0, 10}}, false},
+		{"output never changes", "outputRate", []eval{{100, 0, 10}, {200, 0, 20}, {300, 0, 30}}, true},
+		{"noise changes", "noiseRate", []eval{{100, 0, 10}, {200, 0, 20}}, false},
+		{"output changes once", "outputRate", []eval{{100, 0, 10}, {200, 0, 20}, {300, 2, 30}}, false},
+		{"parameters never change", "outputRate", []eval{{100, 0, 10}, {100, 0, 10}}, false},
+	}
+
+	for _, c := range cases {
+		spec := NewSpec()
+		spec.Parameters = []Parameter{{Property: "Poisson Max", Min: 50, Max: 500}}
+		spec.Targets = []Target{{Metric: c.metric, Value: 20, Tolerance: 0.5, Weight: 1}}
+
+		tuner := NewTuner(spec, nil)
+		for i, e := range c.evals {
+			tuner.Trajectory = append(tuner.Trajectory, &Step{
+				Evaluation: i,
+				Values:     []float64{e.value},
+				Result:     &sweep.Result{OutputRate: e.output, NoiseRate: e.noise},
+			})
+		}
+
+		if err := tuner.flat(); (err != nil) != c.flat {
+			t.Errorf("%s: error %v, expected flat %v", c.name, err, c.flat)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wdevore/Deuron4/simulation"
+	"github.com/wdevore/Deuron4/simulation/samples"
+
+	_ "github.com/wdevore/Deuron4/simulation/continuous"
+)
+
+func newSim(t *testing.T) simulation.ISimulation {
+	sim, err := simulation.New("continuous")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusComm := make(chan simulation.Status)
+	propEventComm := make(chan simulation.PropertyChange)
+	go func() {
+		for range statusComm {
+		}
+	}()
+	go func() {
+		for range propEventComm {
+		}
+	}()
+	sim.Connect(statusComm, propEventComm)
+
+	return sim
+}
+
+// run ticks the sim and returns the frames of the completed windows.
+func run(sim simulation.ISimulation, steps int) []samples.FrameState {
+	frames := []samples.FrameState{}
+	for i := 0; i < steps; i++ {
+		if sim.Tick() {
+			frame := sim.Samples().Acquire().Snapshot()
+			// How often frames are published depends on the wall clock.
+			frame.Seq = 0
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func Test_CheckpointRestoreIsIdentical(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deuron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	sim := newSim(t)
+	if err = sim.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Save mid window so the partially collected samples matter too.
+	run(sim, 1500)
+	if err = sim.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	expected := run(sim, 2500)
+
+	restored := newSim(t)
+	if err = restored.Restore(path); err != nil {
+		t.Fatal(err)
+	}
+	actual := run(restored, 2500)
+
+	if len(expected) == 0 || !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("restored run differs from the uninterrupted run: %d windows vs %d", len(expected), len(actual))
+	}
+}